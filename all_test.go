@@ -2,13 +2,20 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/neelance/peg/peggen"
+	"github.com/neelance/peg/peglib"
+	"github.com/neelance/peg/pegtest"
 	"go/ast"
 	"go/printer"
 	"go/token"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -32,6 +39,17 @@ func TestStringTerminal(t *testing.T) {
 	})
 }
 
+func TestStringTerminalEscapes(t *testing.T) {
+	testRule(t, `'it\'s' / '"' / 'a\tb' / "\x41"`, map[string]string{
+		"it's": "{}",
+		`"`:    "{}",
+		"a\tb": "{}",
+		"a":    "{}",
+		"it":   "null",
+		`\"`:   "null",
+	})
+}
+
 func TestCharacterClassTerminal(t *testing.T) {
 	testRule(t, `[b-df\-h]`, map[string]string{
 		"b": "{}",
@@ -56,6 +74,23 @@ func TestCharacterClassTerminal(t *testing.T) {
 	})
 }
 
+func TestCharacterClassLoop(t *testing.T) {
+	testRule(t, `[a-zA-Z_] [a-zA-Z0-9_]* ( '=' [0-9]+ )?`, map[string]string{
+		"a":       "{}",
+		"_x9":     "{}",
+		"Ab_c=42": "{}",
+		"9":       "null",
+		"a-b":     "null",
+		"a=":      "null",
+	})
+
+	testRule(t, `x:[aeiou]* [^aeiou]`, map[string]string{
+		"b":    `{"x": ""}`,
+		"aeb":  `{"x": "ae"}`,
+		"aeiu": "null",
+	})
+}
+
 func TestAnyCharacterTerminal(t *testing.T) {
 	testRule(t, `.`, map[string]string{
 		"a":  "{}",
@@ -90,6 +125,38 @@ func TestChoice(t *testing.T) {
 	})
 }
 
+func TestChoiceDispatch(t *testing.T) {
+	testRule(t, `( 'if' / 'int' / "else" / [a-z]+ / 'x'? ) '.'`, map[string]string{
+		"if.":   "{}",
+		"int.":  "{}",
+		"ELSE.": "{}",
+		"else.": "{}",
+		"in.":   "{}",
+		"elsa.": "{}",
+		"x.":    "{}",
+		".":     "{}",
+		"X.":    "null",
+		"1.":    "null",
+	})
+
+	testGrammar(t, `
+rule Test
+  ( a / 'b' / !'c' . ) ( &'d' 'de' / 'df' / ( ) )
+end
+rule a
+  [a-c] 'x' / 'c'
+end
+`, "Test", map[string]string{
+		"ax":  "{}",
+		"b":   "{}",
+		"c":   "{}",
+		"by":  "null",
+		"ede": "{}",
+		"edf": "{}",
+		"bd":  "null",
+	})
+}
+
 func TestOptional(t *testing.T) {
 	testRule(t, `'abc'? 'def'`, map[string]string{
 		"abcdef": "{}",
@@ -157,6 +224,43 @@ func TestUntil(t *testing.T) {
 	})
 }
 
+func TestNullableRepetition(t *testing.T) {
+	testRule(t, `( 'a'? )*`, map[string]string{
+		"":    "{}",
+		"aaa": "{}",
+		"aab": "null",
+	})
+
+	testRule(t, `( 'a'? )*[ 'b'? ] 'c'`, map[string]string{
+		"c":     "{}",
+		"abac":  "{}",
+		"aabbc": "{}",
+		"ad":    "null",
+	})
+
+	testRule(t, `( 'a'? )*->'b'`, map[string]string{
+		"b":   "{}",
+		"aab": "{}",
+		"aac": "null",
+	})
+
+	testGrammar(t, `
+rule Test
+  ( a b )*
+end
+rule a
+  'a' / b
+end
+rule b
+  'b'?
+end
+`, "Test", map[string]string{
+		"":     "{}",
+		"abba": "{}",
+		"abc":  "null",
+	})
+}
+
 func TestParenthesizedExpression(t *testing.T) {
 	testRule(t, `( 'a' ( ) 'b' )? 'c'`, map[string]string{
 		"abc": "{}",
@@ -403,6 +507,316 @@ func TestBooleanFunctions(t *testing.T) {
 	})
 }
 
+func TestObjectCreator(t *testing.T) {
+	testRule(t, `Name:[a-z] <Thing>`, map[string]string{
+		"a": `{"Name":"a"}`,
+		"1": "null",
+	})
+
+	testRule(t, `Name:[a-z] <Thing { Kind: 'x\'', Flag: true, List: [ @Name, <Inner { Value: @Name }> ], Missing: @Other }>`, map[string]string{
+		"a": `{"Kind":"x'","Flag":true,"List":["a",{"Value":"a"}],"Missing":null}`,
+	})
+}
+
+func TestSemanticPredicate(t *testing.T) {
+	testRule(t, `c:[a-z] &{ c.(peglib.InputRange).String() != "x" }`, map[string]string{
+		"a": `{"c":"a"}`,
+		"x": "null",
+	})
+
+	testRule(t, `( c:[a-z] !{ c.(peglib.InputRange).String() == "x" } / 'x' 'y' )`, map[string]string{
+		"a":  `{"c":"a"}`,
+		"xy": `{}`,
+		"x":  "null",
+	})
+}
+
+func TestAction(t *testing.T) {
+	testRule(t, `'a' { input = input[1:] } 'c'`, map[string]string{
+		"abc": "{}",
+		"ac":  "null",
+	})
+}
+
+func TestUserState(t *testing.T) {
+	// a typedef is only in effect if the alternative defining it succeeds,
+	// blocks are only kept if followed by '!' and skipped otherwise
+	grammar := `
+rule Program
+  item*[ ' ' ]
+end
+rule item
+  / Block:( '{' item*[ ' ' ] '}' ) '!'
+  / skipped
+  / 'typedef ' Typedef:name { state.define(Typedef.(peglib.InputRange).String()) } ';'
+  / Type:name &{ state.isType(Type.(peglib.InputRange).String()) } ' ' Var:name ';'
+  / Expr:name ';'
+end
+rule skipped
+  @:( '{' ( skipped / [^{}] )* '}' )
+end
+rule name
+  [a-z]+
+end
+`
+	main := `
+type symbols struct {
+	types *typeList
+}
+
+type typeList struct {
+	name string
+	next *typeList
+}
+
+func (s *symbols) define(name string) {
+	s.types = &typeList{name, s.types}
+}
+
+func (s *symbols) isType(name string) bool {
+	for l := s.types; l != nil; l = l.next {
+		if l.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *symbols) Snapshot() interface{}        { return s.types }
+func (s *symbols) Restore(snapshot interface{}) { s.types = snapshot.(*typeList) }
+
+func main() {
+	for input, expected := range map[string]string{
+		"typedef t; t x;":       ` + "`" + `[{"Typedef":"t"},{"Type":"t","Var":"x"}]` + "`" + `,
+		"t x;":                  "null",
+		"{typedef t;}! t x;":    ` + "`" + `[{"Block":[{"Typedef":"t"}]},{"Type":"t","Var":"x"}]` + "`" + `,
+		"{typedef t;} t x;":     "null",
+		"{typedef t;} t;":       ` + "`" + `["{typedef t;}",{"Expr":"t"}]` + "`" + `,
+		"{{typedef t;}!}! t x;": ` + "`" + `[{"Block":[{"Block":[{"Typedef":"t"}]}]},{"Type":"t","Var":"x"}]` + "`" + `,
+		"{{typedef t;}!} t x;":  "null",
+		"{{typedef t;}}! t x;":  "null",
+	} {
+		peglib.UserState = &symbols{}
+		value, _, n, ok := peglib.ParsePrefix(Program, []byte(input))
+		got := "null"
+		if ok && n == len(input) {
+			b, _ := json.Marshal(value)
+			got = string(b)
+		}
+		if got != expected {
+			fmt.Printf("wrong result on %q:\nexpected %s\ngot      %s\n", input, expected, got)
+			os.Exit(1)
+		}
+	}
+
+	peglib.UserState = nil
+	defer func() {
+		if e := recover(); fmt.Sprint(e) != "peglib.UserState must be set to a value of type *symbols" {
+			fmt.Printf("wrong panic: %v\n", e)
+			os.Exit(1)
+		}
+	}()
+	peglib.ParsePrefix(Program, []byte("typedef t;"))
+	fmt.Println("no panic without state")
+	os.Exit(1)
+}
+`
+	runTestProgram(t, grammar, &peggen.Options{StateType: "*symbols"}, []string{"encoding/json", "fmt", "os"}, main)
+}
+
+func TestIndentation(t *testing.T) {
+	testGrammar(t, `
+		rule Test
+			( $Samedent item )*
+		end
+		rule item
+			name:[a-z]+ '\n' ( $Indent children:( $Samedent item )+ $Dedent )?
+		end
+	`, "Test", map[string]string{
+		"a\n":                  `[{"name":"a"}]`,
+		"a\n  b\n  c\nd\n":     `[{"name":"a","children":[{"name":"b"},{"name":"c"}]},{"name":"d"}]`,
+		"a\n  b\n    c\n  d\n": `[{"name":"a","children":[{"name":"b","children":[{"name":"c"}]},{"name":"d"}]}]`,
+		"a\n  b\n c\n":         "null",
+		" a\n":                 "null",
+	})
+}
+
+func TestOperatorPrecedence(t *testing.T) {
+	testGrammar(t, `
+		rule Test
+			$Precedence[ num
+				left '+' / '-'
+				left '*'
+				prefix '-'
+				right '^'
+				postfix '!'
+			]
+		end
+		rule num
+			[0-9]+
+		end
+	`, "Test", map[string]string{
+		"12":     `"12"`,
+		"1+2*3":  `{"op":"+","l":"1","r":{"op":"*","l":"2","r":"3"}}`,
+		"1*2+3":  `{"op":"+","l":{"op":"*","l":"1","r":"2"},"r":"3"}`,
+		"1-2-3":  `{"op":"-","l":{"op":"-","l":"1","r":"2"},"r":"3"}`,
+		"1^2^3":  `{"op":"^","l":"1","r":{"op":"^","l":"2","r":"3"}}`,
+		"-1*2":   `{"op":"*","l":{"op":"-","r":"1"},"r":"2"}`,
+		"-1^2":   `{"op":"-","r":{"op":"^","l":"1","r":"2"}}`,
+		"--1!":   `{"op":"-","r":{"op":"-","r":{"op":"!","l":"1"}}}`,
+		"2^-1":   `{"op":"^","l":"2","r":{"op":"-","r":"1"}}`,
+		"1--2":   `{"op":"-","l":"1","r":{"op":"-","r":"2"}}`,
+		"1*-2+3": `{"op":"+","l":{"op":"*","l":"1","r":{"op":"-","r":"2"}},"r":"3"}`,
+		"1+":     "null",
+		"+1":     "null",
+	})
+
+	// prefix operators directly after binary operators, with operators that
+	// consume trailing whitespace
+	testGrammar(t, `
+		rule Test
+			$Precedence[ num
+				left ( '+' ws ) / ( '-' ws )
+				left ( '*' ws )
+				prefix ( '-' ws ) / ( '!' ws )
+				right ( '^' ws )
+			]
+		end
+		rule num
+			[0-9]+ ws
+		end
+		rule ws
+			[ ]*
+		end
+	`, "Test", map[string]string{
+		"2 ^ -1":     `{"op":"^ ","l":"2 ","r":{"op":"-","r":"1"}}`,
+		"2^-1":       `{"op":"^","l":"2","r":{"op":"-","r":"1"}}`,
+		"1 * -2 + 3": `{"op":"+ ","l":{"op":"* ","l":"1 ","r":{"op":"-","r":"2 "}},"r":"3"}`,
+		"1 - - 2":    `{"op":"- ","l":"1 ","r":{"op":"- ","r":"2"}}`,
+		"2 ^ ! - 1":  `{"op":"^ ","l":"2 ","r":{"op":"! ","r":{"op":"- ","r":"1"}}}`,
+		"2 ^ -":      "null",
+	})
+}
+
+func TestCheck(t *testing.T) {
+	testCheck(t, `
+rule Test
+  a b[%x] missing
+end
+rule a
+  'a'
+end
+rule a
+  'b'
+end
+rule unused
+  'c' unused
+end
+rule b[%x, %y]
+  'b'
+end
+`, nil, []string{
+		"test.peg:3:5: error: rule b takes 2 arguments, called with 1",
+		"test.peg:3:11: error: undefined rule missing",
+		"test.peg:8:6: error: rule a redefined, previous definition at test.peg:5:6",
+		"test.peg:11:6: warning: rule unused is unreachable from the entry rules",
+	})
+
+	// a syntax error in the middle of a rule is not reported at its start,
+	// the end keyword is taken as a rule call within the parentheses
+	testCheck(t, `
+rule A
+  'x'
+end
+rule T
+  ( 'a' 'b'
+end
+`, nil, []string{
+		"test.peg:7:4: error: syntax error: at line 7, column 4 (byte 40, after \"le T\\n  ( 'a' 'b'\\nend\"): unexpected input",
+	})
+
+	testCheck(t, `
+rule a
+  'a'
+end
+rule b
+  a
+end
+`, []string{"b"}, []string{})
+
+	testCheck(t, `
+rule Test
+  ( 'a'? )* ( 'b' / a )+[ a ] ( 'c' )*[ a ] ( [d] / &'e' )*->'f'
+end
+rule a
+  'a'*
+end
+`, nil, []string{
+		"test.peg:3:5: warning: loop body can succeed without consuming input",
+		"test.peg:3:15: warning: loop body can succeed without consuming input",
+		"test.peg:3:47: warning: loop body can succeed without consuming input",
+	})
+
+	testCheck(t, `
+rule Test
+  ( 'a' / 'ab' / "B" / 'b' / [a-c] / 'cd' / [d] [e-f] / 'df' 'g' )
+  ( 'x' / "X" / 'x'* / 'y' )
+end
+`, nil, []string{
+		"test.peg:3:11: warning: alternative is shadowed by the alternative at test.peg:3:5, which matches a prefix of it",
+		"test.peg:3:24: warning: alternative is shadowed by the alternative at test.peg:3:18, which matches a prefix of it",
+		"test.peg:3:38: warning: alternative is shadowed by the alternative at test.peg:3:30, which matches a prefix of it",
+		"test.peg:3:57: warning: alternative is shadowed by the alternative at test.peg:3:45, which matches a prefix of it",
+		"test.peg:4:24: warning: alternative is unreachable, the alternative at test.peg:4:17 always succeeds",
+	})
+
+	testCheck(t, `
+rule Test
+  ( 'it\'s' / 'it\'s me' / '"' / '"a' / '\0' / '\x00' )
+end
+`, nil, []string{
+		"test.peg:3:15: warning: alternative is shadowed by the alternative at test.peg:3:5, which matches a prefix of it",
+		"test.peg:3:34: warning: alternative is shadowed by the alternative at test.peg:3:28, which matches a prefix of it",
+		"test.peg:3:48: warning: alternative is shadowed by the alternative at test.peg:3:41, which matches a prefix of it",
+	})
+
+	testCheck(t, `
+rule Test
+  input:'a' type:'b' peglib:'c' input:'d' state:'e' { _ = input }
+end
+rule Other
+  input:'a' %type:'b'
+end
+`, nil, []string{
+		"test.peg:3:3: error: label input of a rule with Go code is reserved by the generated code",
+		"test.peg:3:13: error: label type of a rule with Go code is reserved by the generated code",
+		"test.peg:3:22: error: label peglib of a rule with Go code is reserved by the generated code",
+	})
+
+	testCheck(t, `
+rule Test
+  # 'a' / 'ab'
+  ( 'ab' / 'a' / 'ab' ) # { x }
+  'c' ( 'c' / 'cd' )
+end
+`, nil, []string{
+		"test.peg:4:18: warning: alternative is shadowed by the alternative at test.peg:4:5, which matches a prefix of it",
+		"test.peg:5:15: warning: alternative is shadowed by the alternative at test.peg:5:9, which matches a prefix of it",
+	})
+
+	testCheck(t, `
+rule Test
+  ( '\q' / 'b' / '\q' ) ( [\q] / [a-\q] / [a] ) 'c' <Thing { Kind: 'x\q' }>
+end
+`, nil, []string{
+		"test.peg:3:5: error: invalid escape sequence in data of <Thing>",
+		"test.peg:3:5: error: invalid escape sequence in string",
+		"test.peg:3:18: error: invalid escape sequence in string",
+		"test.peg:3:27: error: invalid escape sequence in character class",
+		"test.peg:3:34: error: invalid escape sequence in character class",
+	})
+}
+
 // func TestErrorFunction(t *testing.T) {
 //  testRule(t, `'a' $error['test'] 'bc'`, map[string]string {
 //  "abc": "null",
@@ -421,68 +835,1007 @@ func TestBooleanFunctions(t *testing.T) {
 //  "abaX": "null",
 // }
 
-func testRule(t *testing.T, rule string, inputs map[string]string) {
-	testGrammar(t, "rule Test\n"+rule+"\nend\n", "Test", inputs)
+func TestOptimization(t *testing.T) {
+	grammar := `
+rule Test
+  ( ( item ( ',' ws item )* )? ) ';' 'e' 'n' "d"
+end
+rule item
+  ( :letter / :number ) ws
+end
+rule letter
+  ( [a-z] )
+end
+rule number
+  digit+
+end
+rule digit
+  [0-9]
+end
+rule ws
+  ' '*
+end
+`
+	inputs := map[string]string{
+		";end":         "{}",
+		"a, 1 ;enD":    `{"letter": "a"}`,
+		"1 ,b,  2;end": `{"number": "1"}`,
+		"a,;end":       "null",
+		"a 1;end":      "null",
+		"a;eNd":        "null",
+	}
+	for level := 0; level <= 2; level++ {
+		testGrammarWithOptions(t, grammar, "Test", &peggen.Options{OptimizationLevel: level}, inputs)
+	}
+
+	// merged strings with escapes at the boundary
+	escapes := `
+rule Test
+  'a\\' 'n' '\'' '"' '\0' '7'
+end
+`
+	for level := 0; level <= 2; level++ {
+		testGrammarWithOptions(t, escapes, "Test", &peggen.Options{OptimizationLevel: level}, map[string]string{
+			"a\\n'\"\x007": "{}",
+			"a\\n'\"7":     "null",
+			"a\n'\"\x007":  "null",
+		})
+	}
+
+	decls := peggen.Compile(grammar, &peggen.Options{OptimizationLevel: 2})
+	var funcs []string
+	for _, decl := range decls {
+		if f, ok := decl.(*ast.FuncDecl); ok {
+			funcs = append(funcs, f.Name.Name)
+		}
+	}
+	if expected := []string{"Test", "item"}; !reflect.DeepEqual(funcs, expected) {
+		t.Errorf("wrong functions after inlining:\nexpected %q\ngot      %q", expected, funcs)
+	}
 }
 
-func testGrammar(t *testing.T, grammar, mainRule string, inputs map[string]string) {
-	file := &ast.File{
-		Name: ast.NewIdent("main"),
-		Decls: append(
-			[]ast.Decl{
-				&ast.GenDecl{
-					Tok: token.IMPORT,
-					Specs: []ast.Spec{
-						&ast.ImportSpec{
-							Path: &ast.BasicLit{Kind: token.STRING, Value: `"github.com/neelance/peg/peglib"`},
-						},
-					},
-				},
-				&ast.FuncDecl{
-					Name: ast.NewIdent("main"),
-					Type: &ast.FuncType{},
-					Body: &ast.BlockStmt{
-						List: []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{
-							Fun:  &ast.SelectorExpr{X: ast.NewIdent("peglib"), Sel: ast.NewIdent("Test")},
-							Args: []ast.Expr{ast.NewIdent(mainRule)},
-						}}},
-					},
-				},
-			},
-			peggen.Compile(grammar)...,
-		),
-	}
-
-	os.Mkdir("tmp", 0777)
-	testfile, err := os.Create("tmp/test.go")
-	if err != nil {
-		t.Fatal(err)
+func TestOutputModes(t *testing.T) {
+	grammar := `
+rule Test
+  Items:item*[ ',' ] ( ';' Flag:$True / '.' ) Tail:( @:[a-z] )*->'!' &{ Tail != nil }
+end
+rule item
+  @:[0-9]+ / '(' Sum:$Precedence[ [0-9] left '+' / '-' ] ')' / Empty:( )
+end
+`
+	values := map[string]string{
+		";ab!":      `{"Items": [{"Empty": ""}], "Flag": true, "Tail": ["a", "b"]}`,
+		"1,(1+2).!": `{"Items": ["1", {"Sum": {"op": "+", "l": "1", "r": "2"}}], "Tail": []}`,
+		"12,,3;x!":  `{"Items": ["12", {"Empty": ""}, "3"], "Flag": true, "Tail": ["x"]}`,
+		"(1+);!":    "null",
+		"1;aB!":     "null",
+		"1;ab":      "null",
 	}
+	testGrammarWithOptions(t, grammar, "Test", &peggen.Options{Output: peggen.OutputValues}, values)
+	testGrammarWithOptions(t, grammar, "Test", &peggen.Options{Output: peggen.OutputLight}, values)
 
-	printer.Fprint(testfile, token.NewFileSet(), file)
-	testfile.Close()
+	recognized := make(map[string]string)
+	for input, output := range values {
+		if output != "null" {
+			output = "{}"
+		}
+		recognized[input] = output
+	}
+	testGrammarWithOptions(t, grammar, "Test", &peggen.Options{Output: peggen.OutputNone}, recognized)
 
-	for input, expectedJson := range inputs {
-		output, err := exec.Command("go", "run", testfile.Name(), input).CombinedOutput()
-		if err != nil {
-			t.Log(string(output))
-			t.Fatal(err)
+	for _, decl := range peggen.Compile(grammar, &peggen.Options{Output: peggen.OutputNone}) {
+		ast.Inspect(decl, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				switch sel.Sel.Name {
+				case "HasPrefix", "HasPrefixFold", "InputRange", "SaveState", "RestoreState":
+				default:
+					t.Errorf("recognizer uses peglib.%s", sel.Sel.Name)
+				}
+			}
+			return true
+		})
+	}
+}
+
+func TestConcreteSyntaxTree(t *testing.T) {
+	grammar := `
+rule Test
+  list ';' / List:list
+end
+rule list
+  '(' ws ( item ws )* ')'
+end
+rule item
+  [a-z]+
+end
+rule ws
+  ( ' ' / comment )*
+end
+rule comment
+  '#' [^\n]* '\n'
+end
+`
+	items := `
+		{"Rule": "ws", "Text": " ", "Children": [{"Text": " "}]},
+		{"Rule": "item", "Text": "a", "Children": [{"Text": "a"}]},
+		{"Rule": "ws", "Text": " #x\n ", "Children": [
+			{"Text": " "},
+			{"Rule": "comment", "Text": "#x\n", "Children": [{"Text": "#x\n"}]},
+			{"Text": " "}
+		]},
+		{"Rule": "item", "Text": "b", "Children": [{"Text": "b"}]},
+		{"Rule": "ws", "Text": ""},
+	`
+	for level := 0; level <= 2; level++ {
+		testGrammarWithOptions(t, grammar, "Test", &peggen.Options{Output: peggen.OutputCST, OptimizationLevel: level}, map[string]string{
+			"( a #x\n b)": `{"Rule": "Test", "Text": "( a #x\n b)", "Children": [
+				{"Rule": "list", "Text": "( a #x\n b)", "Children": [{"Text": "("},` + items + `{"Text": ")"}]}
+			]}`,
+			"( a #x\n b);": `{"Rule": "Test", "Text": "( a #x\n b);", "Children": [
+				{"Rule": "list", "Text": "( a #x\n b)", "Children": [{"Text": "("},` + items + `{"Text": ")"}]},
+				{"Text": ";"}
+			]}`,
+			"(a": "null",
+		})
+	}
+}
+
+func TestNodeTokens(t *testing.T) {
+	input := []byte("f(x)\x00")
+	peglib.Reset()
+	mark := peglib.Mark()
+	peglib.MakeNode("name", peglib.Mark(), input, input[1:])
+	peglib.MakeNode("arg", peglib.Mark(), input[2:], input[3:])
+	peglib.MakeNode("call", mark, input, input[4:])
+	var tokens []string
+	peglib.Top().(*peglib.Node).Tokens(func(token *peglib.Node) {
+		tokens = append(tokens, token.Text.String())
+	})
+	peglib.Reset()
+	if expected := []string{"f", "(", "x", ")"}; !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("wrong tokens:\nexpected %q\ngot      %q", expected, tokens)
+	}
+}
+
+func TestIncrementalReparse(t *testing.T) {
+	grammar := `
+rule Document
+  ws ( value ws )*
+end
+rule value
+  list / [a-z0-9]+
+end
+rule list
+  '(' ws ( value ws )* ')'
+end
+rule ws
+  ( [ \n] / comment )*
+end
+rule comment
+  '#' [^\n]* '\n'
+end
+`
+	// compares the incremental parse with a full parse after each random edit
+	main := `
+// inInput reports whether the texts of the tree of n refer to input, and not
+// to the input before an edit.
+func inInput(n *peglib.Node, input []byte) bool {
+	offset := cap(input) - cap(n.Text)
+	if offset < 0 || offset >= len(input) || &input[offset] != &n.Text[:1][0] {
+		return false
+	}
+	for _, c := range n.Children {
+		if !inInput(c, input) {
+			return false
 		}
+	}
+	return true
+}
 
-		var expected, got interface{}
-		if err := json.Unmarshal([]byte(expectedJson), &expected); err != nil {
-			t.Fatal(err)
+func main() {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []byte("ab1() #\n")
+	doc := peglib.ParseDocument(Document, []byte("(a (b 1) #c\n) x (y)"))
+	reused := 0
+	for i := 0; i < 500; i++ {
+		input := doc.Input()
+		edit := peglib.Edit{Offset: r.Intn(len(input) + 1)}
+		if max := len(input) - edit.Offset; max != 0 {
+			edit.Deleted = r.Intn(max%3 + 1)
 		}
-		if err := json.Unmarshal(output, &got); err != nil {
-			t.Fatal(err)
+		for n := r.Intn(3); n > 0; n-- {
+			edit.Inserted = append(edit.Inserted, alphabet[r.Intn(len(alphabet))])
+		}
+		doc.Reparse(edit)
+
+		full := peglib.ParseDocument(Document, doc.Input())
+		got, _ := json.Marshal(doc.Result())
+		expected, _ := json.Marshal(full.Result())
+		gotLength, gotOk := doc.Match()
+		expectedLength, expectedOk := full.Match()
+		if string(got) != string(expected) || gotLength != expectedLength || gotOk != expectedOk {
+			fmt.Printf("wrong result after edit %d in %q:\nexpected %d %t %s\ngot      %d %t %s\n", i, doc.Input(), expectedLength, expectedOk, expected, gotLength, gotOk, got)
+			os.Exit(1)
+		}
+		if n, ok := doc.Result().(*peglib.Node); ok && !inInput(n, doc.Input()[:len(doc.Input())+1]) {
+			fmt.Printf("result after edit %d refers to an earlier input\n", i)
+			os.Exit(1)
+		}
+		reused += doc.Hits() - full.Hits()
+	}
+	if reused <= 0 {
+		fmt.Println("no results reused")
+		os.Exit(1)
+	}
+}
+`
+	for _, output := range []peggen.OutputMode{peggen.OutputCST, peggen.OutputNone} {
+		runTestProgram(t, grammar, &peggen.Options{Output: output, Memoize: true}, []string{"encoding/json", "fmt", "math/rand", "os"}, main)
+	}
+
+	var diags []string
+	for _, d := range peggen.Check("rule Test\n  'a' &{ true }\nend\n", &peggen.Options{Filename: "test.peg", Memoize: true}) {
+		diags = append(diags, d.String())
+	}
+	if expected := []string{
+		"test.peg: error: memoization requires the cst or none output mode",
+		"test.peg:2:9: error: memoized rules cannot contain Go code or indentation functions",
+	}; !reflect.DeepEqual(diags, expected) {
+		t.Errorf("wrong diagnostics:\nexpected %q\ngot      %q", expected, diags)
+	}
+}
+
+func TestStream(t *testing.T) {
+	grammar := `
+rule Events
+  ( event $Commit )*
+end
+rule event
+  Name:[a-z]+ '=' Value:[0-9]* [ \n]*
+end
+`
+	// compares the events emitted while reading small chunks with a full parse
+	main := `
+type chunkReader struct {
+	r    *rand.Rand
+	data []byte
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(c.data) == 0 {
+		return 0, io.EOF
+	}
+	n := c.r.Intn(8)
+	if n > len(p) {
+		n = len(p)
+	}
+	if n > len(c.data) {
+		n = len(c.data)
+	}
+	copy(p, c.data[:n])
+	c.data = c.data[n:]
+	return n, nil
+}
+
+func main() {
+	r := rand.New(rand.NewSource(1))
+	var input []byte
+	for i := 0; i < 20000; i++ {
+		input = append(input, "abc"[:r.Intn(3)+1]...)
+		if i == 10000 {
+			input = append(input, bytes.Repeat([]byte("x"), 200000)...)
+		}
+		input = append(input, '=')
+		if r.Intn(4) != 0 {
+			input = strconv.AppendInt(input, int64(r.Intn(1000)), 10)
+		}
+		input = append(input, "  \n"[r.Intn(3):]...)
+	}
+
+	Events(append(input, 0))
+	var expected []string
+	for _, v := range peglib.Top().([]interface{}) {
+		b, _ := json.Marshal(v)
+		expected = append(expected, string(b))
+	}
+	peglib.Reset()
+
+	var got []string
+	err := peglib.Stream(Events, &chunkReader{r: r, data: input}, func(v interface{}) error {
+		b, _ := json.Marshal(v)
+		got = append(got, string(b))
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(got, expected) {
+		fmt.Printf("wrong events: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = peglib.Stream(Events, strings.NewReader("a=1\nb=2\n!c=3\n"), func(v interface{}) error { return nil })
+	if e, ok := err.(*peglib.StreamError); !ok || e.Offset != 8 {
+		fmt.Printf("wrong error: %v\n", err)
+		os.Exit(1)
+	}
+
+	stop := errors.New("stop")
+	if err := peglib.Stream(Events, strings.NewReader("a=1\nb=2\n"), func(v interface{}) error { return stop }); err != stop {
+		fmt.Printf("wrong error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// the long event does not fit into the window, the others do
+	long := "a=1\nb=22\n" + strings.Repeat("c", 5000) + "=3\nd=4\n"
+	var events int
+	err = peglib.StreamWith(Events, &chunkReader{r: r, data: []byte(long)}, peglib.StreamOptions{MaxWindow: 100}, func(v interface{}) error {
+		events++
+		return nil
+	})
+	if e, ok := err.(*peglib.WindowError); !ok || e.Offset != 9 || e.Size != 100 || events != 2 {
+		fmt.Printf("wrong error: %v after %d events\n", err, events)
+		os.Exit(1)
+	}
+	if err := peglib.StreamWith(Events, strings.NewReader(long), peglib.StreamOptions{MaxWindow: 6000}, func(v interface{}) error { return nil }); err != nil {
+		fmt.Printf("wrong error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// reading a long event byte by byte does not reparse it after every byte
+	var parses int
+	events = 0
+	long = strings.Repeat("x", 100000) + "=1\ny=2\n"
+	err = peglib.Stream(func(input []byte) []byte {
+		parses++
+		return Events(input)
+	}, iotest.OneByteReader(strings.NewReader(long)), func(v interface{}) error {
+		events++
+		return nil
+	})
+	if err != nil || events != 2 || parses > 200 {
+		fmt.Printf("wrong result: %v after %d events and %d parses\n", err, events, parses)
+		os.Exit(1)
+	}
+}
+`
+	for _, level := range []int{0, 2} {
+		runTestProgram(t, grammar, &peggen.Options{OptimizationLevel: level, Streaming: true}, []string{"bytes", "encoding/json", "errors", "fmt", "io", "math/rand", "os", "reflect", "strconv", "strings", "testing/iotest"}, main)
+	}
+
+	for opts, expected := range map[*peggen.Options]string{
+		&peggen.Options{Filename: "test.peg"}: "test.peg:2:7: warning: $Commit has no effect without the streaming option",
+		&peggen.Options{Filename: "test.peg", Output: peggen.OutputNone, Memoize: true, Streaming: true}: "test.peg: error: memoization cannot be combined with streaming",
+	} {
+		diags := peggen.Check("rule Test\n  'a' $Commit\nend\n", opts)
+		if len(diags) != 1 || diags[0].String() != expected {
+			t.Errorf("wrong diagnostics:\nexpected %q\ngot      %q", expected, diags)
+		}
+	}
+
+	// $Commit always succeeds
+	testCheck(t, "rule Test\n  'a' ( $Commit / 'b' )\nend\n", nil, []string{
+		"test.peg:2:9: warning: $Commit has no effect without the streaming option",
+		"test.peg:2:19: warning: alternative is unreachable, the alternative at test.peg:2:9 always succeeds",
+	})
+}
+
+func TestParsePrefix(t *testing.T) {
+	grammar := `
+rule Token
+  [ ]* ( Number:[0-9]+ / Name:[a-z]+ / Operator:[+*] )
+end
+`
+	// tokenizes the input by parsing consecutive prefixes
+	main := `
+func main() {
+	input := []byte("12+ab *3")
+	var tokens []string
+	rest, offset := input, 0
+	for len(rest) != 0 {
+		value, next, n, ok := peglib.ParsePrefix(Token, rest)
+		if !ok {
+			fmt.Printf("no token at %d\n", offset)
+			os.Exit(1)
+		}
+		if len(tokens) != 0 && &next[0:1][0] != &rest[n:n+1][0] {
+			fmt.Println("input copied again")
+			os.Exit(1)
+		}
+		b, _ := json.Marshal(value)
+		tokens = append(tokens, string(b))
+		rest, offset = next, offset+n
+	}
+	if got := strings.Join(tokens, " "); got != ` + "`" + `{"Number":"12"} {"Operator":"+"} {"Name":"ab"} {"Operator":"*"} {"Number":"3"}` + "`" + ` || offset != len(input) {
+		fmt.Printf("wrong tokens: %s\n", got)
+		os.Exit(1)
+	}
+
+	if _, rest, n, ok := peglib.ParsePrefix(Token, []byte("12!")); !ok || string(rest) != "!" || n != 2 {
+		fmt.Printf("wrong prefix: %q %d %t\n", rest, n, ok)
+		os.Exit(1)
+	}
+	if _, rest, n, ok := peglib.ParsePrefix(Token, []byte("!")); ok || string(rest) != "!" || n != 0 {
+		fmt.Printf("wrong failure: %q %d %t\n", rest, n, ok)
+		os.Exit(1)
+	}
+}
+`
+	runTestProgram(t, grammar, nil, []string{"encoding/json", "fmt", "os", "strings"}, main)
+}
+
+func TestLimits(t *testing.T) {
+	grammar := `
+rule Value
+  '(' List:Value* ')' / X:'x' / Sum:$Precedence[ 'y' prefix '-' ]
+end
+rule Slow
+  'a' Slow 'b' / 'a' Slow 'c' / 'a'
+end
+`
+	main := `
+func check(input string, limits peglib.Limits, ctx context.Context, expected string) {
+	value, err := peglib.ParseContext(ctx, Value, []byte(input), limits)
+	got := fmt.Sprintf("%T", err)
+	if err == nil {
+		got = fmt.Sprintf("%T", value)
+	}
+	if got != expected {
+		fmt.Printf("%.20q: expected %s, got %s (%v)\n", input, expected, got, err)
+		os.Exit(1)
+	}
+}
+
+func main() {
+	ctx := context.Background()
+	deep := strings.Repeat("(", 100000) + "x" + strings.Repeat(")", 100000)
+	check(deep, peglib.Limits{MaxDepth: 1000}, ctx, "*peglib.DepthError")
+	check(strings.Repeat("-", 100000)+"y", peglib.Limits{MaxDepth: 1000}, ctx, "*peglib.DepthError")
+	check("(((x)))", peglib.Limits{MaxDepth: 1000}, ctx, "map[string]interface {}")
+	check("(xxxxxxxx)", peglib.Limits{MaxSteps: 5}, ctx, "*peglib.StepError")
+	check("(xxxxxxxx)", peglib.Limits{MaxSteps: 100}, ctx, "map[string]interface {}")
+	check("(xxxxxxxx)", peglib.Limits{MaxOutput: 5}, ctx, "*peglib.OutputError")
+	check("(xxxxxxxx", peglib.Limits{}, ctx, "*peglib.ParsingError")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	check("x", peglib.Limits{}, canceled, "*peglib.CanceledError")
+	long := "(" + strings.Repeat("x", 5000) + ")"
+	check(long, peglib.Limits{}, ctx, "map[string]interface {}")
+	_, err := peglib.ParseContext(canceled, Value, []byte(long), peglib.Limits{})
+	if !errors.Is(err, context.Canceled) {
+		fmt.Printf("wrong error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// backtracks exponentially, so the parse is canceled while it runs
+	deadline, cancelDeadline := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelDeadline()
+	started := time.Now()
+	_, err = peglib.ParseContext(deadline, Slow, []byte(strings.Repeat("a", 60)), peglib.Limits{})
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(started) > 10*time.Second {
+		fmt.Printf("wrong error: %v after %v\n", err, time.Since(started))
+		os.Exit(1)
+	}
+}
+`
+	runTestProgram(t, grammar, &peggen.Options{Limits: true}, []string{"context", "errors", "fmt", "os", "strings", "time"}, main)
+}
+
+func TestTrace(t *testing.T) {
+	grammar := `
+rule Sum
+  Left:number '+' Right:number
+end
+rule number
+  [0-9]+
+end
+`
+	main := `
+func main() {
+	var b strings.Builder
+	peglib.Trace = peglib.NewTextTracer(&b)
+	Sum([]byte("1+23\x00"))
+	Sum([]byte("1+x\x00"))
+	fmt.Print(b.String())
+}
+`
+	expected := `Sum at 0
+  number at 0
+  number matched 0-1
+  number at 2
+  number matched 2-4
+Sum matched 0-4
+Sum at 0
+  number at 0
+  number matched 0-1
+  number at 2
+  number failed at 2
+Sum failed at 0
+`
+	output := runTestProgramOutput(t, grammar, &peggen.Options{OptimizationLevel: 2, Trace: true}, []string{"fmt", "strings"}, main)
+	if output != expected {
+		t.Errorf("wrong trace:\nexpected %s\ngot      %s", expected, output)
+	}
+
+	// abandoned parses end the running invocations
+	grammar = `
+rule Events
+  ( event $Commit )*
+end
+rule event
+  Name:[a-z]+ '=' Value:( '(' event* ')' / [0-9]* ) [ \n]*
+end
+`
+	main = `
+// checker is a Tracer verifying that the calls match.
+type checker struct {
+	running []string
+	spans   []string
+}
+
+func (c *checker) Enter(rule string, pos int) {
+	c.running = append(c.running, fmt.Sprint(rule, " ", pos))
+}
+
+func (c *checker) Leave(rule string, pos, end int, ok bool) {
+	if n := len(c.running); n == 0 || c.running[n-1] != fmt.Sprint(rule, " ", pos) {
+		fmt.Printf("%s left at %d while running %q\n", rule, pos, c.running)
+		os.Exit(1)
+	}
+	c.running = c.running[:len(c.running)-1]
+	if rule == "event" && ok {
+		c.spans = append(c.spans, fmt.Sprintf("%d-%d", pos, end))
+	}
+}
+
+func main() {
+	c := &checker{}
+	peglib.Trace = c
+	if err := peglib.Stream(Events, strings.NewReader("a=1\nb=(c=2)\nd=3\n"), func(v interface{}) error { return nil }); err != nil {
+		panic(err)
+	}
+	fmt.Println(c.spans, len(c.running))
+
+	c.spans = nil
+	_, err := peglib.ParseContext(context.Background(), Events, []byte("a=(b=(c=(d=1)))\n"), peglib.Limits{MaxDepth: 4})
+	fmt.Printf("%T %d\n", err, len(c.running))
+
+	tracer := peglib.NewChromeTracer()
+	peglib.Trace = tracer
+	if err := peglib.Stream(Events, strings.NewReader("a=1\nb=2\n"), func(v interface{}) error { return nil }); err != nil {
+		panic(err)
+	}
+	var b strings.Builder
+	tracer.WriteJSON(&b)
+	fmt.Println(strings.Count(b.String(), ` + "`" + `"rule":"Events"` + "`" + `))
+}
+`
+	// the last event is matched again after reading the end of the input
+	expected = `[0-4 7-10 4-12 12-16 12-16] 0
+*peglib.DepthError 0
+4
+`
+	output = runTestProgramOutput(t, grammar, &peggen.Options{Trace: true, Streaming: true, Limits: true}, []string{"context", "fmt", "os", "strings"}, main)
+	if output != expected {
+		t.Errorf("wrong trace:\nexpected %s\ngot      %s", expected, output)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	grammar := `
+rule Expr
+  number '+' Expr / number '-' Expr / number
+end
+rule number
+  [0-9]+
+end
+`
+	main := `
+func main() {
+	p := peglib.NewProfiler()
+	peglib.Trace = p
+	Expr([]byte("1+2\x00"))
+	rules := p.Rules()
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Rule < rules[j].Rule })
+	for _, r := range rules {
+		if r.Inclusive < r.Exclusive || r.Exclusive <= 0 {
+			fmt.Printf("wrong times of %s\n", r.Rule)
+		}
+		fmt.Println(r.Rule, r.Invocations, r.Successes, r.Failures, r.Consumed)
+	}
+	fmt.Println(p.HotSpots(10))
+
+	f, err := os.Create(os.Args[1])
+	if err != nil {
+		panic(err)
+	}
+	if err := p.WritePprof(f); err != nil {
+		panic(err)
+	}
+	f.Close()
+}
+`
+	profile := filepath.Join(t.TempDir(), "profile.pb.gz")
+	output := runTestProgramOutput(t, grammar, &peggen.Options{Trace: true}, []string{"fmt", "os", "sort"}, main, profile)
+	expected := `Expr 2 2 0 4
+number 4 4 0 4
+[{number 2 3}]
+`
+	if output != expected {
+		t.Errorf("wrong profile:\nexpected %s\ngot      %s", expected, output)
+	}
+
+	top, err := exec.Command("go", "tool", "pprof", "-top", "-sample_index=invocations", profile).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s%s", top, err)
+	}
+	if !regexp.MustCompile(`(?m)^\s+4\s.*\snumber$`).Match(top) || !regexp.MustCompile(`(?m)^\s+2\s.*\sExpr$`).Match(top) {
+		t.Errorf("wrong pprof output:\n%s", top)
+	}
+}
+
+func TestChromeTrace(t *testing.T) {
+	grammar := `
+rule Sum
+  number ( '+' number )*
+end
+rule number
+  [0-9]+
+end
+`
+	main := `
+func main() {
+	tracer := peglib.NewChromeTracer()
+	peglib.Trace = tracer
+	Sum([]byte("1+23+\x00"))
+	tracer.WriteJSON(os.Stdout)
+}
+`
+	output := runTestProgramOutput(t, grammar, &peggen.Options{Trace: true}, []string{"os"}, main)
+	var trace struct {
+		TraceEvents []struct {
+			Name string
+			Ph   string
+			Ts   float64
+			Dur  float64
+			Args map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal([]byte(output), &trace); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range trace.TraceEvents {
+		names = append(names, e.Name)
+		if e.Ph != "X" || e.Dur < 0 {
+			t.Errorf("wrong event %+v", e)
+		}
+	}
+	if expected := []string{"Sum 0-4", "number 0-1", "number 2-4", "number 5 failed"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("wrong spans:\nexpected %q\ngot      %q", expected, names)
+	}
+	if len(names) == 4 {
+		sum, last := trace.TraceEvents[0], trace.TraceEvents[3]
+		if last.Ts < sum.Ts || last.Ts+last.Dur > sum.Ts+sum.Dur {
+			t.Errorf("span %q not within %q", last.Name, sum.Name)
 		}
-		if !reflect.DeepEqual(expected, got) {
-			t.Errorf("grammar %q gave wrong result on %q:\nexpected %#v\ngot      %#v", grammar, input, expected, got)
+		if last.Args["rule"] != "number" || last.Args["start"] != 5.0 || last.Args["matched"] != false {
+			t.Errorf("wrong arguments %v", last.Args)
+		}
+	}
+}
+
+func TestDebugger(t *testing.T) {
+	grammar := `
+rule Sum
+  Left:number '+' Right:number
+end
+rule number
+  [0-9]+
+end
+`
+	main := `
+func main() {
+	input := []byte("1+23")
+	commands := "b @2\nc\nbt\noutput\no\nn\n\n"
+	peglib.Trace = peglib.NewDebugger(input, strings.NewReader(commands), os.Stdout)
+	Sum(append(input, 0))
+}
+`
+	expected := `-> Sum at 0 (line 1, column 1): "1+23"
+(peg) (peg)   -> number at 2 (line 1, column 3): "23"
+(peg) number at 2
+Sum at 0
+(peg) 0: {"Left":"1"}
+(peg)   <- number matched 2-4 "23"
+(peg) <- Sum matched 0-4 "1+23"
+(peg) `
+	output := runTestProgramOutput(t, grammar, &peggen.Options{Trace: true}, []string{"os", "strings"}, main)
+	if output != expected {
+		t.Errorf("wrong transcript:\nexpected %s\ngot      %s", expected, output)
+	}
+}
+
+func TestREPL(t *testing.T) {
+	script := `rule Sum Left:number '+' Right:number
+rule number
+  [0-9]+
+end
+1+23
+"1+\n2"
+:entry number
+42
+rule number [a-z]+
+:entry Sum
+a+b
+:rules
+:delete number
+1+2
+`
+	expected := `> 2:8: error: undefined rule number
+2:25: error: undefined rule number
+> ... ... > {"Left":"1","Right":"23"}
+> error: at line 1, column 2 (byte 2, after "1+"): no match
+> > {}
+> > > {"Left":"a","Right":"b"}
+> rule Sum
+  Left:number '+' Right:number
+end
+rule number
+  [a-z]+
+end
+entry rule: Sum
+> > 2:8: error: undefined rule number
+2:25: error: undefined rule number
+> 
+`
+	var out strings.Builder
+	r := newREPL(&out)
+	r.run(strings.NewReader(script))
+	r.stop()
+	if out.String() != expected {
+		t.Errorf("wrong transcript:\nexpected %s\ngot      %s", expected, out.String())
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	grammar := `
+		rule Sum
+			Left:number '+' Right:number
+		end
+		rule number
+			[0-9]+
+		end
+		rule Hello
+			'hello' / "hi" '!'
+		end
+	`
+	dir := t.TempDir()
+	cases := map[string]string{
+		"Sum/ok.txt":      "1+23\n--- expected\n{\"Right\": \"23\", \"Left\": \"1\"}\n",
+		"Sum/error.txt":   "1+\n--- expected\nerror at 2\n",
+		"Sum/wrong.txt":   "1+2\n--- expected\n{\"Left\": \"1\", \"Right\": \"3\"}\n",
+		"Sum/new.txt":     "4+5",
+		"number/dash.txt": "4-\n--- expected\nerror at 1\n",
+		"Hello/typo.txt":  "helxo\n--- expected\nerror at 3\n",
+		"Hello/fold.txt":  "Hi?\n--- expected\nerror at 2\n",
+		"Hello/empty.txt": "--- expected\nerror at 0\n",
+		"Sum/empty.txt":   "",
+	}
+	for name, content := range cases {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0777)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0666); err != nil {
+			t.Fatal(err)
 		}
 	}
+	main := `
+var rules = map[string]func([]byte) []byte{"Sum": Sum, "number": number, "Hello": Hello}
+
+func main() {
+	for _, update := range []bool{false, true, false} {
+		failed, err := pegtest.RunCorpus(os.Args[1], rules, update, os.Stdout)
+		fmt.Println(failed, err)
+	}
+	// like a rule generated without the limits option
+	plain := func(input []byte) []byte { return input[len(input)-1:] }
+	fmt.Println((&pegtest.Case{}).Result(plain))
+}
+`
+	output := runTestProgramOutput(t, grammar, &peggen.Options{EntryRules: []string{"Sum", "number", "Hello"}, Limits: true}, []string{"fmt", "os", "github.com/neelance/peg/pegtest"}, main, dir)
+	expected := `FAIL Sum/empty.txt
+  no expected result, got:
+  + error at 0
+FAIL Sum/new.txt
+  no expected result, got:
+  + {
+  +   "Left": "4",
+  +   "Right": "5"
+  + }
+FAIL Sum/wrong.txt
+    {
+      "Left": "1",
+  -   "Right": "3"
+  +   "Right": "2"
+    }
+3 <nil>
+updated 3 of 9 cases
+ok 9 cases
+0 <nil>
+ok 9 cases
+0 <nil>
+error: peglib: rule not generated with the limits option
+`
+	if output != expected {
+		t.Errorf("wrong report:\nexpected %s\ngot      %s", expected, output)
+	}
+	updated, err := ioutil.ReadFile(filepath.Join(dir, "Sum/wrong.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(updated) != "1+2\n--- expected\n{\n  \"Left\": \"1\",\n  \"Right\": \"2\"\n}\n" {
+		t.Errorf("wrong updated case: %q", updated)
+	}
+	updated, err = ioutil.ReadFile(filepath.Join(dir, "Sum/empty.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(updated) != "--- expected\nerror at 0\n" {
+		t.Errorf("wrong updated empty case: %q", updated)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	grammar := `
+		rule Extra
+			'a' { peglib.PushTrue() } { peglib.PushTrue() }
+		end
+	`
+	p := pegtest.Build(t, grammar, &peggen.Options{})
+	if entries, _ := filepath.Glob(".pegtest*"); len(entries) != 0 {
+		t.Errorf("temporary files in the current directory: %v", entries)
+	}
+	if _, err := p.Parse("Extra", []byte("a")); err == nil || err.Error() != "2 values left on the output stack" {
+		t.Errorf("wrong error for extra values: %v", err)
+	}
+}
+
+// eventRecorder is an OutputBuilder writing its events in a compact notation.
+type eventRecorder struct {
+	strings.Builder
+}
+
+func (r *eventRecorder) BeginObject(class string)  { r.WriteString(class + "{") }
+func (r *eventRecorder) EndObject()                { r.WriteString("}") }
+func (r *eventRecorder) BeginLabel(name string)    { r.WriteString(name + ":") }
+func (r *eventRecorder) EndLabel()                 { r.WriteString(" ") }
+func (r *eventRecorder) BeginArray()               { r.WriteString("[") }
+func (r *eventRecorder) EndArray()                 { r.WriteString("]") }
+func (r *eventRecorder) Range(v peglib.InputRange) { fmt.Fprintf(r, "%q", v) }
+func (r *eventRecorder) Value(v interface{})       { fmt.Fprint(r, v) }
+
+func TestOutputBuilder(t *testing.T) {
+	input := []byte("a+b,c")
+	at := func(i int) []byte { return input[i:] }
+
+	// values that are not labels are dropped from objects, also after backtracking
+	peglib.Reset()
+	peglib.PushInputRange(at(0), at(1))
+	peglib.MakeLabel("A")
+	peglib.PushArray()
+	peglib.PushInputRange(at(1), at(2))
+	peglib.PushInputRange(at(2), at(3))
+	peglib.Pop(2) // backtracking
+	peglib.PushInputRange(at(1), at(2))
+	peglib.PushInputRange(at(1), at(2))
+	peglib.PushInputRange(at(2), at(3))
+	peglib.MakePrefix()
+	peglib.MakeLabel("Op")
+	peglib.MergeLabels(2)
+	peglib.AppendToArray()
+	peglib.PushEmpty()
+	peglib.AppendToArray()
+	peglib.PushInputRange(at(3), at(4))
+	peglib.PushTrue()
+	peglib.MakeArray(2)
+	peglib.MakeLabel("B")
+	peglib.MergeLabels(3)
+
+	var r eventRecorder
+	peglib.Build(&r)
+	if expected := `{A:"a" B:[","true] }`; r.String() != expected {
+		t.Errorf("wrong events:\nexpected %s\ngot      %s", expected, r.String())
+	}
+
+	// labels on their own are objects, labels of nested objects are merged
+	peglib.Reset()
+	peglib.PushArray()
+	peglib.PushInputRange(at(1), at(2))
+	peglib.PushInputRange(at(2), at(3))
+	peglib.MakePrefix()
+	peglib.MakeLabel("Op")
+	peglib.AppendToArray()
+	peglib.PushInputRange(at(3), at(4))
+	peglib.MakeLabel("Comma")
+	peglib.PushEmpty()
+	peglib.MergeLabels(2)
+	peglib.AppendToArray()
+	r.Reset()
+	peglib.Build(&r)
+	if expected := `[{Op:{op:"+" r:"b" } }{Comma:"," }]`; r.String() != expected {
+		t.Errorf("wrong events:\nexpected %s\ngot      %s", expected, r.String())
+	}
+	value, _ := json.Marshal(peglib.Top())
+	if expected := `[{"Op":{"op":"+","r":"b"}},{"Comma":","}]`; string(value) != expected {
+		t.Errorf("wrong value:\nexpected %s\ngot      %s", expected, value)
+	}
+
+	// values are built once, appending only builds the array again
+	first := peglib.Top().([]interface{})[0]
+	peglib.PushInputRange(at(4), at(5))
+	peglib.AppendToArray()
+	array := peglib.Top().([]interface{})
+	if len(array) != 3 || reflect.ValueOf(array[0]).Pointer() != reflect.ValueOf(first).Pointer() {
+		t.Errorf("wrong value after appending: %v", array)
+	}
+	if again := peglib.Top().([]interface{}); &again[0] != &array[0] {
+		t.Error("value built again")
+	}
+	peglib.Reset()
+}
+
+func testCheck(t *testing.T, grammar string, entryRules []string, expected []string) {
+	got := []string{}
+	for _, d := range peggen.Check(grammar, &peggen.Options{Filename: "test.peg", EntryRules: entryRules}) {
+		got = append(got, d.String())
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("wrong diagnostics for grammar %q:\nexpected %q\ngot      %q", grammar, expected, got)
+	}
+}
+
+func testRule(t *testing.T, rule string, inputs map[string]string) {
+	testGrammar(t, "rule Test\n"+rule+"\nend\n", "Test", inputs)
+}
+
+func testGrammar(t *testing.T, grammar, mainRule string, inputs map[string]string) {
+	testGrammarWithOptions(t, grammar, mainRule, nil, inputs)
+}
+
+// runTestProgram runs a program made of the parser for grammar and the given
+// Go source, which must contain the function main.
+func runTestProgram(t *testing.T, grammar string, opts *peggen.Options, imports []string, src string) {
+	runTestProgramOutput(t, grammar, opts, imports, src)
+}
+
+// runTestProgramOutput is like runTestProgram, passing args to the program,
+// and returns its output.
+func runTestProgramOutput(t *testing.T, grammar string, opts *peggen.Options, imports []string, src string, args ...string) string {
+	dir, err := ioutil.TempDir(".", ".pegtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	testfile, err := os.Create(filepath.Join(dir, "test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(testfile, "package main\n\nimport %q\n", "github.com/neelance/peg/peglib")
+	for _, path := range imports {
+		fmt.Fprintf(testfile, "import %q\n", path)
+	}
+	fmt.Fprintln(testfile, src)
+	for _, decl := range peggen.Compile(grammar, opts) {
+		printer.Fprint(testfile, token.NewFileSet(), decl)
+		fmt.Fprintln(testfile)
+	}
+	testfile.Close()
 
-	if !t.Failed() {
-		os.Remove(testfile.Name())
-		os.Remove("tmp")
+	output, err := exec.Command("go", append([]string{"run", testfile.Name()}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Log(string(output))
+		t.Fatal(err)
 	}
+	return string(output)
+}
+
+func testGrammarWithOptions(t *testing.T, grammar, mainRule string, opts *peggen.Options, inputs map[string]string) {
+	pegtest.Build(t, grammar, opts).Test(t, mainRule, inputs)
 }
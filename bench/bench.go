@@ -0,0 +1,34 @@
+// Package bench measures the speed of generated parsers. Each subpackage
+// contains a grammar, the parser generated from it and benchmarks parsing a
+// large generated input. The subpackages of json benchmark the same grammar
+// with the light, concrete syntax tree and recognize-only output modes.
+//
+// After changing the code generator, regenerate the parsers and compare with
+// a previous run:
+//
+//	go generate ./bench/...
+//	go test -run NONE -bench . -benchmem -count 5 ./bench/... > new.txt
+//	peg benchcmp old.txt new.txt
+package bench
+
+import (
+	"testing"
+
+	"github.com/neelance/peg/peglib"
+)
+
+// Run benchmarks parsing input with rule and building its output with
+// peglib.ValueBuilder. The input must not contain the terminating zero byte.
+func Run(b *testing.B, rule func([]byte) []byte, input []byte) {
+	input = append(input[:len(input):len(input)], 0)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		peglib.Reset()
+		if rest := rule(input); len(rest) != 1 {
+			b.Fatal("parsing failed")
+		}
+		peglib.Top()
+	}
+}
@@ -0,0 +1,35 @@
+package csv
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/neelance/peg/bench"
+)
+
+// generateInput returns a table with plain and quoted fields, about 1 MB in
+// size.
+func generateInput() []byte {
+	r := rand.New(rand.NewSource(1))
+	var buf bytes.Buffer
+	buf.WriteString("id,date,amount,description,comment\r\n")
+	for i := 0; i < 12000; i++ {
+		fmt.Fprintf(&buf, "%d,2016-%02d-%02d,%d.%02d,", i, r.Intn(12)+1, r.Intn(28)+1, r.Intn(10000), r.Intn(100))
+		if r.Intn(3) == 0 {
+			fmt.Fprintf(&buf, "\"item %d, \"\"special\"\"\",", r.Intn(1000))
+		} else {
+			fmt.Fprintf(&buf, "item %d,", r.Intn(1000))
+		}
+		if r.Intn(2) == 0 {
+			buf.WriteString("\"multi\nline\"")
+		}
+		buf.WriteString("\r\n")
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkParse(b *testing.B) {
+	bench.Run(b, File, generateInput())
+}
@@ -0,0 +1,4 @@
+// Package csv contains a parser generated from csv.peg for benchmarking.
+package csv
+
+//go:generate go run github.com/neelance/peg generate -package csv -O 2 -o parser.go csv.peg
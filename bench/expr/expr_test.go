@@ -0,0 +1,65 @@
+package expr
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/neelance/peg/bench"
+)
+
+var binaryOperators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "^"}
+
+func generateExpression(r *rand.Rand, buf *bytes.Buffer, depth int) {
+	if depth == 0 {
+		switch r.Intn(3) {
+		case 0:
+			fmt.Fprintf(buf, "%d", r.Intn(1000))
+		case 1:
+			fmt.Fprintf(buf, "%d.%d", r.Intn(100), r.Intn(100))
+		default:
+			fmt.Fprintf(buf, "v%d", r.Intn(100))
+		}
+		return
+	}
+	switch r.Intn(6) {
+	case 0:
+		buf.WriteString("(")
+		generateExpression(r, buf, depth-1)
+		buf.WriteString(")")
+	case 1:
+		buf.WriteString("-")
+		generateExpression(r, buf, depth-1)
+	case 2:
+		fmt.Fprintf(buf, "f%d(", r.Intn(10))
+		generateExpression(r, buf, depth-1)
+		buf.WriteString(", ")
+		generateExpression(r, buf, depth-1)
+		buf.WriteString(")")
+	default:
+		generateExpression(r, buf, depth-1)
+		fmt.Fprintf(buf, " %s ", binaryOperators[r.Intn(len(binaryOperators))])
+		generateExpression(r, buf, depth-1)
+	}
+}
+
+// generateInput returns a program of assignments with random expressions,
+// about 1 MB in size.
+func generateInput() []byte {
+	r := rand.New(rand.NewSource(1))
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < 1<<20; i++ {
+		if i%10 == 0 {
+			fmt.Fprintf(&buf, "# block %d\n", i/10)
+		}
+		fmt.Fprintf(&buf, "v%d = ", i%100)
+		generateExpression(r, &buf, 4)
+		buf.WriteString(";\n")
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkParse(b *testing.B) {
+	bench.Run(b, Program, generateInput())
+}
@@ -0,0 +1,4 @@
+// Package expr contains a parser generated from expr.peg for benchmarking.
+package expr
+
+//go:generate go run github.com/neelance/peg generate -package expr -O 2 -o parser.go expr.peg
@@ -0,0 +1,7 @@
+// Package ident contains a parser generated from ident.peg for benchmarking
+// the code generated for character classes: the start of identifiers and
+// whitespace are matched by comparisons, the rest of identifiers and operators
+// by table lookups, and the repetitions by loops that only advance an index.
+package ident
+
+//go:generate go run github.com/neelance/peg generate -package ident -O 2 -o parser.go ident.peg
@@ -0,0 +1,65 @@
+package ident
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/neelance/peg/bench"
+	"github.com/neelance/peg/peglib"
+)
+
+const (
+	identStart = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_"
+	identChars = identStart + "0123456789"
+	operators  = "-+*/%<>!&|^"
+)
+
+// generateInput returns assignments of expressions over identifiers with
+// comments in between, about 1 MB in size.
+func generateInput() []byte {
+	r := rand.New(rand.NewSource(1))
+	identifier := func(buf *bytes.Buffer) {
+		buf.WriteByte(identStart[r.Intn(len(identStart))])
+		for n := r.Intn(20); n > 0; n-- {
+			buf.WriteByte(identChars[r.Intn(len(identChars))])
+		}
+	}
+	var buf bytes.Buffer
+	for buf.Len() < 1<<20 {
+		if r.Intn(4) == 0 {
+			buf.WriteString("# the value of the next identifier, computed from the ones before\n")
+		}
+		identifier(&buf)
+		buf.WriteString(" = ")
+		for n := r.Intn(5); ; n-- {
+			if r.Intn(4) == 0 {
+				buf.WriteString("42")
+			} else {
+				identifier(&buf)
+			}
+			if n <= 0 {
+				break
+			}
+			buf.WriteString(" " + string(operators[r.Intn(len(operators))]) + " ")
+		}
+		buf.WriteString(";\n")
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkParse(b *testing.B) {
+	bench.Run(b, File, generateInput())
+}
+
+func BenchmarkRecognize(b *testing.B) {
+	input := generateInput()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if n, ok := peglib.Recognize(File, input); !ok || n != len(input) {
+			b.Fatal("recognizing failed")
+		}
+	}
+}
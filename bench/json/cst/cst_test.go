@@ -0,0 +1,12 @@
+package cst
+
+import (
+	"testing"
+
+	"github.com/neelance/peg/bench"
+	"github.com/neelance/peg/bench/json"
+)
+
+func BenchmarkParse(b *testing.B) {
+	bench.Run(b, Value, json.GenerateInput())
+}
@@ -0,0 +1,5 @@
+// Package cst contains a parser generated from ../json.peg that builds a
+// concrete syntax tree, for benchmarking.
+package cst
+
+//go:generate go run github.com/neelance/peg generate -package cst -O 2 -output cst -o parser.go ../json.peg
@@ -0,0 +1,4 @@
+// Package json contains a parser generated from json.peg for benchmarking.
+package json
+
+//go:generate go run github.com/neelance/peg generate -package json -O 2 -o parser.go json.peg
@@ -0,0 +1,31 @@
+package json
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+)
+
+// GenerateInput returns an array of objects resembling records of an API
+// response, about 1 MB in size. It is shared with the benchmarks of the other
+// output modes.
+func GenerateInput() []byte {
+	r := rand.New(rand.NewSource(1))
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i := 0; i < 4000; i++ {
+		if i != 0 {
+			buf.WriteString(",\n")
+		}
+		fmt.Fprintf(&buf, `  {"id": %d, "name": "user \"%d\"", "score": %.3f, "active": %t, "manager": null, "tags": [`, i, r.Intn(100000), r.Float64()*100, r.Intn(2) == 0)
+		for j := r.Intn(8); j > 0; j-- {
+			fmt.Fprintf(&buf, `"tag%d"`, r.Intn(50))
+			if j != 1 {
+				buf.WriteString(", ")
+			}
+		}
+		fmt.Fprintf(&buf, `], "position": {"x": %d, "y": %d, "z": -%de-%d}}`, r.Intn(1000), r.Intn(1000), r.Intn(10), r.Intn(10))
+	}
+	buf.WriteString("\n]\n")
+	return buf.Bytes()
+}
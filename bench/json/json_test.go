@@ -0,0 +1,11 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/neelance/peg/bench"
+)
+
+func BenchmarkParse(b *testing.B) {
+	bench.Run(b, Value, GenerateInput())
+}
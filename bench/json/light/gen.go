@@ -0,0 +1,5 @@
+// Package light contains a parser generated from ../json.peg with light output
+// for benchmarking.
+package light
+
+//go:generate go run github.com/neelance/peg generate -package light -O 2 -output light -o parser.go ../json.peg
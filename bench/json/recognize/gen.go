@@ -0,0 +1,5 @@
+// Package recognize contains a parser generated from ../json.peg that only
+// recognizes its input, for benchmarking.
+package recognize
+
+//go:generate go run github.com/neelance/peg generate -package recognize -O 2 -output none -o parser.go ../json.peg
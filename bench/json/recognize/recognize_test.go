@@ -0,0 +1,25 @@
+package recognize
+
+import (
+	"testing"
+
+	"github.com/neelance/peg/bench"
+	"github.com/neelance/peg/bench/json"
+	"github.com/neelance/peg/peglib"
+)
+
+func BenchmarkParse(b *testing.B) {
+	bench.Run(b, Value, json.GenerateInput())
+}
+
+func BenchmarkRecognize(b *testing.B) {
+	input := json.GenerateInput()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if n, ok := peglib.Recognize(Value, input); !ok || n != len(input) {
+			b.Fatal("recognizing failed")
+		}
+	}
+}
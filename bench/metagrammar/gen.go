@@ -0,0 +1,5 @@
+// Package metagrammar contains a parser generated from the metagrammar of
+// peggen for benchmarking.
+package metagrammar
+
+//go:generate go run github.com/neelance/peg generate -package metagrammar -entry Grammar -O 2 -o parser.go ../../peggen/metagrammar.peg
@@ -0,0 +1,35 @@
+package metagrammar
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/neelance/peg/bench"
+)
+
+// generateInput returns the grammars of the benchmarks, repeated to about
+// 128 kB in size. The input is smaller than those of the other benchmarks, as
+// the metagrammar backtracks heavily on nested parentheses.
+func generateInput(b *testing.B) []byte {
+	var grammars [][]byte
+	for _, filename := range []string{"../json/json.peg", "../csv/csv.peg", "../expr/expr.peg", "../../peggen/metagrammar.peg"} {
+		grammar, err := ioutil.ReadFile(filename)
+		if err != nil {
+			b.Fatal(err)
+		}
+		grammars = append(grammars, grammar)
+	}
+	var buf bytes.Buffer
+	for buf.Len() < 1<<17 {
+		for _, grammar := range grammars {
+			buf.Write(grammar)
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkParse(b *testing.B) {
+	bench.Run(b, Grammar, generateInput(b))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// benchcmp compares two files containing the output of go test -bench and
+// prints the change of each measurement. Repeated runs of a benchmark, e.g.
+// from -count, are averaged.
+func benchcmp(args []string) int {
+	fs := flag.NewFlagSet("benchcmp", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: peg benchcmp old.txt new.txt\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	old, err := readBenchmarks(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	new, err := readBenchmarks(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "benchmark\tunit\told\tnew\tdelta\t")
+	for _, name := range old.names {
+		newResult, ok := new.results[name]
+		if !ok {
+			continue
+		}
+		oldResult := old.results[name]
+		for _, unit := range benchmarkUnits {
+			o, ok1 := oldResult.mean(unit)
+			n, ok2 := newResult.mean(unit)
+			if !ok1 || !ok2 {
+				continue
+			}
+			delta := "~"
+			if o != 0 {
+				delta = fmt.Sprintf("%+.2f%%", (n-o)/o*100)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%.2f\t%.2f\t%s\t\n", name, unit, o, n, delta)
+		}
+	}
+	w.Flush()
+	return 0
+}
+
+var benchmarkUnits = []string{"ns/op", "MB/s", "B/op", "allocs/op"}
+
+type benchmarkResult map[string][]float64
+
+func (r benchmarkResult) mean(unit string) (float64, bool) {
+	values := r[unit]
+	if len(values) == 0 {
+		return 0, false
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values)), true
+}
+
+type benchmarkSet struct {
+	names   []string // in order of first appearance
+	results map[string]benchmarkResult
+}
+
+// readBenchmarks parses lines like
+//
+//	BenchmarkParse-8   100   1234 ns/op   56.7 MB/s   89 B/op   1 allocs/op
+//
+// and names the benchmarks after the last element of the preceding package
+// line, e.g. json.BenchmarkParse-8.
+func readBenchmarks(filename string) (*benchmarkSet, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	set := &benchmarkSet{results: make(map[string]benchmarkResult)}
+	pkg := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "pkg:" {
+			pkg = fields[1][strings.LastIndex(fields[1], "/")+1:] + "."
+			continue
+		}
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		name := pkg + fields[0]
+		result, ok := set.results[name]
+		if !ok {
+			result = make(benchmarkResult)
+			set.results[name] = result
+			set.names = append(set.names, name)
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			result[fields[i+1]] = append(result[fields[i+1]], v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/neelance/peg/peggen"
+)
+
+// debugMain runs the rule named by the format argument in the debugger.
+const debugMain = `import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/neelance/peg/peglib"
+)
+
+func main() {
+	input, err := ioutil.ReadFile(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	d := peglib.NewDebugger(input, os.Stdin, os.Stdout)
+	for _, b := range os.Args[2:] {
+		if err := d.Break(b); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		d.Continue()
+	}
+	peglib.Trace = d
+	rest := %s(append(input, 0))
+	peglib.Trace = nil
+	switch {
+	case rest == nil:
+		fmt.Println("no match")
+		os.Exit(1)
+	case len(rest) != 1:
+		fmt.Printf("matched %%d of %%d bytes\n", len(input)+1-len(rest), len(input))
+		os.Exit(1)
+	}
+	json.NewEncoder(os.Stdout).Encode(peglib.Top())
+}
+`
+
+// debug runs a rule of a grammar on an input file in the interactive
+// debugger of peglib.
+func debug(args []string) int {
+	fs := flag.NewFlagSet("debug", flag.ExitOnError)
+	rule := fs.String("rule", "", "`name` of the rule to run (default the first entry rule)")
+	breaks := fs.String("break", "", "comma-separated `rules` or @offsets to stop at, instead of the first rule")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: peg debug [flags] grammar.peg input\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	filename := fs.Arg(0)
+	grammar, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	name, err := ruleOrDefault(string(grammar), *rule)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	opts := &peggen.Options{Filename: filename, EntryRules: []string{name}, Trace: true}
+	exe, cleanup, err := buildProgram(string(grammar), opts, fmt.Sprintf(debugMain, name))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer cleanup()
+
+	programArgs := []string{fs.Arg(1)}
+	if *breaks != "" {
+		programArgs = append(programArgs, strings.Split(*breaks, ",")...)
+	}
+	cmd := exec.Command(exe, programArgs...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			return exit.ExitCode()
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
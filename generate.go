@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/neelance/peg/peggen"
+)
+
+// generate compiles a grammar file to the Go source of a parser.
+func generate(args []string) int {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	out := fs.String("o", "", "write the parser to `file` instead of standard output")
+	pkg := fs.String("package", "main", "`name` of the generated package")
+	level := fs.Int("O", 0, "optimization `level`")
+	state := fs.String("state", "", "Go `type` of peglib.UserState")
+	entry := fs.String("entry", "", "comma-separated `rules` called by users of the parser")
+	output := fs.String("output", "values", "output `mode`: values, light, cst or none")
+	memoize := fs.Bool("memoize", false, "remember rule results for incremental reparsing")
+	streaming := fs.Bool("streaming", false, "support parsing from an io.Reader with peglib.Stream")
+	limits := fs.Bool("limits", false, "support resource limits and cancellation with peglib.ParseContext")
+	trace := fs.Bool("trace", false, "report rule invocations to peglib.Trace")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: peg generate [flags] grammar.peg\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	filename := fs.Arg(0)
+	grammar, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	opts := &peggen.Options{
+		Filename:          filename,
+		StateType:         *state,
+		OptimizationLevel: *level,
+		Memoize:           *memoize,
+		Streaming:         *streaming,
+		Limits:            *limits,
+		Trace:             *trace,
+	}
+	switch *output {
+	case "values":
+		opts.Output = peggen.OutputValues
+	case "light":
+		opts.Output = peggen.OutputLight
+	case "cst":
+		opts.Output = peggen.OutputCST
+	case "none":
+		opts.Output = peggen.OutputNone
+	default:
+		fmt.Fprintf(os.Stderr, "unknown output mode %q\n", *output)
+		return 2
+	}
+	if *entry != "" {
+		opts.EntryRules = strings.Split(*entry, ",")
+	}
+	diags := peggen.Check(string(grammar), opts)
+	for _, d := range diags {
+		fmt.Fprintln(os.Stderr, d)
+	}
+	if len(diags.Errors()) != 0 {
+		return 1
+	}
+
+	src, err := generateSource(string(grammar), *pkg, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return 0
+	}
+	if err := ioutil.WriteFile(*out, src, 0666); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// generateSource returns the formatted source of a file in package pkg
+// containing the parser for grammar.
+func generateSource(grammar, pkg string, opts *peggen.Options) ([]byte, error) {
+	file := &ast.File{
+		Name: ast.NewIdent(pkg),
+		Decls: append(
+			[]ast.Decl{
+				&ast.GenDecl{
+					Tok: token.IMPORT,
+					Specs: []ast.Spec{
+						&ast.ImportSpec{
+							Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote("github.com/neelance/peg/peglib")},
+						},
+					},
+				},
+			},
+			peggen.Compile(grammar, opts)...,
+		),
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by peg generate from %s. DO NOT EDIT.\n\n", opts.Filename)
+	if err := format.Node(&buf, token.NewFileSet(), file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,5 +1,52 @@
+// Command peg works with grammars for the peg parser generator.
+//
+// Usage:
+//
+//	peg <command> [arguments]
+//
+// The commands are:
+//
+//	benchcmp  compare two benchmark runs
+//	debug     step through the rules matching an input
+//	generate  compile a grammar to a Go parser
+//	repl      define rules and parse inputs interactively
+//	test      run a corpus of inputs and expected results
+//	vet       report likely mistakes in grammars
 package main
 
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+var commands = map[string]func(args []string) int{
+	"benchcmp": benchcmp,
+	"debug":    debug,
+	"generate": generate,
+	"repl":     repl,
+	"test":     test,
+	"vet":      vet,
+}
+
 func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "peg: unknown command %q\n", os.Args[1])
+		usage()
+	}
+	os.Exit(cmd(os.Args[2:]))
+}
 
+func usage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "usage: peg <command> [arguments]\n\ncommands: %v\n", names)
+	os.Exit(2)
 }
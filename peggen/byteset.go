@@ -0,0 +1,124 @@
+package peggen
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// byteSet is a set of input bytes.
+type byteSet [4]uint64
+
+func (s *byteSet) add(b byte) {
+	s[b/64] |= 1 << (b % 64)
+}
+
+func (s *byteSet) contains(b byte) bool {
+	return s[b/64]&(1<<(b%64)) != 0
+}
+
+func (s *byteSet) union(t *byteSet) {
+	for i := range s {
+		s[i] |= t[i]
+	}
+}
+
+func (s *byteSet) isSubsetOf(t *byteSet) bool {
+	for i := range s {
+		if s[i]&^t[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *byteSet) invert() {
+	for i := range s {
+		s[i] = ^s[i]
+	}
+}
+
+// ranges returns the contiguous ranges of bytes in s as pairs of the first and
+// last byte.
+func (s *byteSet) ranges() [][2]byte {
+	var ranges [][2]byte
+	for b := 0; b < 256; b++ {
+		if !s.contains(byte(b)) {
+			continue
+		}
+		if n := len(ranges); n != 0 && int(ranges[n-1][1]) == b-1 {
+			ranges[n-1][1] = byte(b)
+			continue
+		}
+		ranges = append(ranges, [2]byte{byte(b), byte(b)})
+	}
+	return ranges
+}
+
+// charClassChars returns the characters listed in e as a string, ignoring
+// whether e is inverted.
+func charClassChars(e *CharacterClassTerminal) (string, error) {
+	var selections []rune
+	for _, sel := range e.Selections {
+		switch s := sel.(type) {
+		case *CharacterClassSingleCharacter:
+			char, err := unquoteChar(s.Char)
+			if err != nil {
+				return "", err
+			}
+			selections = append(selections, char)
+		case *CharacterClassRange:
+			begin, err := unquoteChar(s.BeginChar)
+			if err != nil {
+				return "", err
+			}
+			end, err := unquoteChar(s.EndChar)
+			if err != nil {
+				return "", err
+			}
+			for i := begin; i <= end; i++ {
+				selections = append(selections, i)
+			}
+		}
+	}
+	return string(selections), nil
+}
+
+// charClassSet returns the set of bytes matched by e.
+func charClassSet(e *CharacterClassTerminal) (byteSet, error) {
+	var set byteSet
+	if len(e.Selections) == 0 {
+		set.invert()
+		return set, nil
+	}
+	chars, err := charClassChars(e)
+	if err != nil {
+		return set, err
+	}
+	for i := 0; i < len(chars); i++ {
+		set.add(chars[i])
+	}
+	if e.Inverted {
+		set.invert()
+	}
+	return set, nil
+}
+
+// errInvalidEscape is returned for strings and characters of a grammar with
+// an unknown escape sequence.
+var errInvalidEscape = errors.New("invalid escape sequence")
+
+func unquoteChar(s fmt.Stringer) (rune, error) {
+	str := s.String()
+	switch str {
+	case `\-`:
+		return '-', nil
+	case `\0`:
+		return 0, nil
+	}
+	char, _, _, err := strconv.UnquoteChar(str, 0)
+	if err != nil {
+		return 0, errInvalidEscape
+	}
+	return char, nil
+}
@@ -0,0 +1,171 @@
+package peggen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// maxRangeComparisons is the number of byte ranges up to which a character
+// class is matched by comparisons instead of a table lookup.
+const maxRangeComparisons = 3
+
+func (c *Context) compileCharClass(e *CharacterClassTerminal, onFailure func() []ast.Stmt) []ast.Stmt {
+	set, _ := charClassSet(e) // valid, see Context.check
+	if set == allBytes {
+		return append(c.examine(intConst(1)), consumeInput(intConst(1)))
+	}
+	return append(c.examine(intConst(1)),
+		&ast.IfStmt{
+			Cond: c.byteSetCond(set, &ast.IndexExpr{X: input, Index: intConst(0)}, false),
+			Body: &ast.BlockStmt{List: append(c.fail(intConst(0)), onFailure()...)},
+		},
+		consumeInput(intConst(1)),
+	)
+}
+
+// charClassLoop returns the character class repeated by e, if e can be
+// compiled to a loop that only advances an index.
+func charClassLoop(e *Repetition) (*CharacterClassTerminal, bool) {
+	if e.GlueExpression != nil {
+		return nil, false
+	}
+	child := e.Child
+	for {
+		paren, ok := child.(*ParenthesizedExpression)
+		if !ok {
+			break
+		}
+		child = paren.Child
+	}
+	class, ok := child.(*CharacterClassTerminal)
+	return class, ok
+}
+
+func (c *Context) compileCharClassLoop(e *Repetition, class *CharacterClassTerminal, onFailure func() []ast.Stmt) []ast.Stmt {
+	i := newIdent("i")
+	set, _ := charClassSet(class) // valid, see Context.check
+	matches := c.byteSetCond(set, &ast.IndexExpr{X: input, Index: i}, true)
+	if b, ok := matches.(*ast.BinaryExpr); ok && b.Op == token.LOR {
+		matches = &ast.ParenExpr{X: matches}
+	}
+	cond := &ast.BinaryExpr{
+		X:  &ast.BinaryExpr{X: i, Op: token.LSS, Y: &ast.CallExpr{Fun: ast.NewIdent("len"), Args: []ast.Expr{input}}},
+		Op: token.LAND,
+		Y:  matches,
+	}
+	stmts := []ast.Stmt{
+		simpleDefine(i, intConst(0)),
+		&ast.ForStmt{
+			Cond: cond,
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.IncDecStmt{X: i, Tok: token.INC}}},
+		},
+	}
+	// the byte ending the loop has been examined as well, and the class
+	// failed on it
+	stmts = append(stmts, c.examine(&ast.BinaryExpr{X: i, Op: token.ADD, Y: intConst(1)})...)
+	stmts = append(stmts, c.fail(i)...)
+	if e.AtLeastOnce {
+		stmts = append(stmts, &ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: i, Op: token.EQL, Y: intConst(0)},
+			Body: &ast.BlockStmt{List: onFailure()},
+		})
+	}
+	stmts = append(stmts, consumeInput(i))
+	return []ast.Stmt{&ast.BlockStmt{List: stmts}}
+}
+
+// byteSetCond returns a condition that is true if the byte x is in set, or
+// not in set if want is false. Sets consisting of few ranges are tested by
+// comparisons, others by a lookup in a table shared by all equal sets.
+func (c *Context) byteSetCond(set byteSet, x ast.Expr, want bool) ast.Expr {
+	ranges := set.ranges()
+	complement := set
+	complement.invert()
+	complementRanges := complement.ranges()
+
+	if len(ranges) > maxRangeComparisons && len(complementRanges) > maxRangeComparisons {
+		table, ok := c.byteSetTables[set]
+		if !ok {
+			table = newIdent("charClass")
+			c.byteSetTables[set] = table
+			var elts []ast.Expr
+			for _, word := range set {
+				elts = append(elts, &ast.BasicLit{Kind: token.INT, Value: fmt.Sprintf("%#x", word)})
+			}
+			c.helperDecls = append(c.helperDecls, &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{&ast.ValueSpec{
+					Names:  []*ast.Ident{table},
+					Values: []ast.Expr{&ast.CompositeLit{Type: &ast.SelectorExpr{X: ast.NewIdent("peglib"), Sel: ast.NewIdent("ByteSet")}, Elts: elts}},
+				}},
+			})
+		}
+		cond := ast.Expr(&ast.CallExpr{Fun: &ast.SelectorExpr{X: table, Sel: ast.NewIdent("Contains")}, Args: []ast.Expr{x}})
+		if !want {
+			cond = not(cond)
+		}
+		return cond
+	}
+
+	if len(complementRanges) <= len(ranges) {
+		ranges = complementRanges
+		want = !want
+	}
+
+	if len(ranges) == 0 {
+		return ast.NewIdent(fmt.Sprint(!want))
+	}
+	var cond ast.Expr
+	for _, r := range ranges {
+		term := rangeCond(r, x, want)
+		if len(ranges) > 1 && !want && r[0] != r[1] && r[0] != 0 && r[1] != 255 {
+			term = &ast.ParenExpr{X: term}
+		}
+		switch {
+		case cond == nil:
+			cond = term
+		case want:
+			cond = &ast.BinaryExpr{X: cond, Op: token.LOR, Y: term}
+		default:
+			cond = &ast.BinaryExpr{X: cond, Op: token.LAND, Y: term}
+		}
+	}
+	return cond
+}
+
+// rangeCond returns a condition that is true if x is in r, or not in r if
+// want is false.
+func rangeCond(r [2]byte, x ast.Expr, want bool) ast.Expr {
+	lo, hi := byteConst(r[0]), byteConst(r[1])
+	if r[0] == r[1] {
+		if want {
+			return &ast.BinaryExpr{X: x, Op: token.EQL, Y: lo}
+		}
+		return &ast.BinaryExpr{X: x, Op: token.NEQ, Y: lo}
+	}
+	if want {
+		switch {
+		case r[0] == 0:
+			return &ast.BinaryExpr{X: x, Op: token.LEQ, Y: hi}
+		case r[1] == 255:
+			return &ast.BinaryExpr{X: x, Op: token.GEQ, Y: lo}
+		}
+		return &ast.BinaryExpr{
+			X:  &ast.BinaryExpr{X: x, Op: token.GEQ, Y: lo},
+			Op: token.LAND,
+			Y:  &ast.BinaryExpr{X: x, Op: token.LEQ, Y: hi},
+		}
+	}
+	switch {
+	case r[0] == 0:
+		return &ast.BinaryExpr{X: x, Op: token.GTR, Y: hi}
+	case r[1] == 255:
+		return &ast.BinaryExpr{X: x, Op: token.LSS, Y: lo}
+	}
+	return &ast.BinaryExpr{
+		X:  &ast.BinaryExpr{X: x, Op: token.LSS, Y: lo},
+		Op: token.LOR,
+		Y:  &ast.BinaryExpr{X: x, Op: token.GTR, Y: hi},
+	}
+}
@@ -0,0 +1,257 @@
+package peggen
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic is a problem found in a grammar.
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Message)
+}
+
+type Diagnostics []Diagnostic
+
+func (l Diagnostics) Error() string {
+	lines := make([]string, len(l))
+	for i, d := range l {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Errors returns the diagnostics with severity Error.
+func (l Diagnostics) Errors() Diagnostics {
+	var errs Diagnostics
+	for _, d := range l {
+		if d.Severity == Error {
+			errs = append(errs, d)
+		}
+	}
+	return errs
+}
+
+// Check parses grammar and reports undefined and duplicate rules, calls with
+// the wrong number of arguments, rules unreachable from the entry rules,
+// loops whose body can succeed without consuming input, alternatives of
+// ordered choices that are never selected and options that the grammar does
+// not support.
+// Compile refuses grammars for which Check reports errors.
+//
+// Check, Compile and EntryRules parse grammar with a parser generated by peg,
+// so they must not run concurrently with other parsers using peglib.
+func Check(grammar string, opts *Options) Diagnostics {
+	c, err := parse(grammar, opts)
+	if err != nil {
+		return err.(Diagnostics)
+	}
+	return c.check()
+}
+
+func (c *Context) check() Diagnostics {
+	var diags Diagnostics
+	report := func(pos token.Position, severity Severity, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{Pos: pos, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, rule := range c.ruleList {
+		if first := c.Rules[rule.RuleName.String()]; first != rule {
+			report(rule.Pos, Error, "rule %s redefined, previous definition at %s", rule.RuleName, first.Pos)
+		}
+		hasCode, reservedLabels := containsCode(rule.Child), make(map[string]bool)
+		walkExpr(rule.Child, func(expr ParsingExpression) {
+			switch e := expr.(type) {
+			case *Label:
+				name := e.Name.String()
+				if hasCode && !e.IsLocal && c.reservedName(name) && !reservedLabels[name] {
+					reservedLabels[name] = true
+					report(e.Pos, Error, "label %s of a rule with Go code is reserved by the generated code", name)
+				}
+			case *StringTerminal:
+				if _, err := stringTerminalValue(e); err != nil {
+					report(e.Pos, Error, "%v in string", err)
+				}
+			case *CharacterClassTerminal:
+				if _, err := charClassChars(e); err != nil {
+					report(e.Pos, Error, "%v in character class", err)
+				}
+			case *ObjectCreator:
+				if err := checkData(e.Data); err != nil {
+					pos := exprPos(e)
+					if !pos.IsValid() {
+						pos = rule.Pos
+					}
+					report(pos, Error, "%v in data of <%s>", err, e.ClassName)
+				}
+			case *RuleCall:
+				callee, ok := c.Rules[e.Name.String()]
+				if !ok {
+					report(e.Pos, Error, "undefined rule %s", e.Name)
+					return
+				}
+				if len(e.Arguments) != len(callee.Parameters) {
+					report(e.Pos, Error, "rule %s takes %d arguments, called with %d", e.Name, len(callee.Parameters), len(e.Arguments))
+				}
+			case *Repetition, *Until:
+				if c.loopsWithoutProgress(e) {
+					pos := exprPos(e)
+					if !pos.IsValid() {
+						pos = rule.Pos
+					}
+					report(pos, Warning, "loop body can succeed without consuming input")
+				}
+			case *PositivePredicate, *NegativePredicate, *Action, *IndentFunction, *SamedentFunction, *DedentFunction:
+				if c.Options.Memoize {
+					pos := exprPos(e)
+					if !pos.IsValid() {
+						pos = rule.Pos
+					}
+					report(pos, Error, "memoized rules cannot contain Go code or indentation functions")
+				}
+			case *CommitFunction:
+				if !c.Options.Streaming {
+					pos := exprPos(e)
+					if !pos.IsValid() {
+						pos = rule.Pos
+					}
+					report(pos, Warning, "$Commit has no effect without the streaming option")
+				}
+			case *Choice:
+				for _, s := range shadowedAlternatives(e) {
+					pos, byPos := exprPos(s.Alternative), exprPos(s.By)
+					if !pos.IsValid() {
+						pos = rule.Pos
+					}
+					if s.Unreachable {
+						report(pos, Warning, "alternative is unreachable, the alternative at %s always succeeds", byPos)
+					} else {
+						report(pos, Warning, "alternative is shadowed by the alternative at %s, which matches a prefix of it", byPos)
+					}
+				}
+			}
+		})
+	}
+
+	if c.Options.Memoize && c.Options.Output != OutputCST && c.Options.Output != OutputNone {
+		report(token.Position{Filename: c.Options.Filename}, Error, "memoization requires the cst or none output mode")
+	}
+	if c.Options.Memoize && c.Options.Streaming {
+		report(token.Position{Filename: c.Options.Filename}, Error, "memoization cannot be combined with streaming")
+	}
+	for _, name := range c.entryRules() {
+		if _, ok := c.Rules[name]; !ok {
+			report(token.Position{Filename: c.Options.Filename}, Error, "undefined entry rule %s", name)
+		}
+	}
+	reachable := c.reachableRules()
+	for _, rule := range c.ruleList {
+		if !reachable[rule] && c.Rules[rule.RuleName.String()] == rule {
+			report(rule.Pos, Warning, "rule %s is unreachable from the entry rules", rule.RuleName)
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Pos.Offset < diags[j].Pos.Offset
+	})
+	return diags
+}
+
+// checkData returns an error if a string in the data of an object creator is
+// invalid.
+func checkData(data interface{}) error {
+	switch d := data.(type) {
+	case *StringData:
+		_, err := unescape(d.String.String())
+		return err
+	case *HashData:
+		for _, entry := range d.Entries {
+			if err := checkData(entry.(*HashDataEntry).Data); err != nil {
+				return err
+			}
+		}
+	case *ArrayData:
+		for _, entry := range d.Entries {
+			if err := checkData(entry.(*ArrayDataEntry).Data); err != nil {
+				return err
+			}
+		}
+	case *ObjectData:
+		return checkData(d.Data)
+	}
+	return nil
+}
+
+// reachableRules returns the rules that can be called from the entry rules.
+func (c *Context) reachableRules() map[*Rule]bool {
+	reachable := make(map[*Rule]bool)
+	var visit func(rule *Rule)
+	visit = func(rule *Rule) {
+		if reachable[rule] {
+			return
+		}
+		reachable[rule] = true
+		walkExpr(rule.Child, func(expr ParsingExpression) {
+			if call, ok := expr.(*RuleCall); ok {
+				if callee, ok := c.Rules[call.Name.String()]; ok {
+					visit(callee)
+				}
+			}
+		})
+	}
+	for _, name := range c.entryRules() {
+		if rule, ok := c.Rules[name]; ok {
+			visit(rule)
+		}
+	}
+	return reachable
+}
+
+// EntryRules returns the names of the rules called by users of the parser for
+// grammar, see Options.EntryRules.
+func EntryRules(grammar string, opts *Options) ([]string, error) {
+	c, err := parse(grammar, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.entryRules(), nil
+}
+
+// entryRules returns the names of the rules called by users of the parser.
+func (c *Context) entryRules() []string {
+	if len(c.Options.EntryRules) != 0 {
+		return c.Options.EntryRules
+	}
+	var names []string
+	for _, rule := range c.ruleList {
+		name := rule.RuleName.String()
+		if name[0] >= 'A' && name[0] <= 'Z' {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 && len(c.ruleList) != 0 {
+		names = append(names, c.ruleList[0].RuleName.String())
+	}
+	return names
+}
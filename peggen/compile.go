@@ -1,85 +1,55 @@
 package peggen
 
 import (
-	"github.com/neelance/jetpeg"
+	"fmt"
 	"go/ast"
 	"go/token"
 	"strconv"
+	"strings"
 )
 
 type Context struct {
-	Rules map[string]*Rule
+	Rules   map[string]*Rule
+	Options *Options
+
+	ruleList      []*Rule
+	usesState     bool
+	labelVars     map[string]bool
+	helperDecls   []ast.Decl
+	byteSetTables map[byteSet]*ast.Ident
 }
 
 func (c *Context) compileExpr(expr ParsingExpression, onFailure func() []ast.Stmt) []ast.Stmt {
 	switch e := expr.(type) {
 	case *StringTerminal:
-		str, err := strconv.Unquote(`"` + e.Chars.String() + `"`)
-		if err != nil {
-			panic(err)
-		}
+		str, _ := stringTerminalValue(e) // valid, see Context.check
 		hasPrefixFun := "HasPrefix"
 		if e.Fold {
 			hasPrefixFun = "HasPrefixFold"
 		}
-		return []ast.Stmt{
+		failFun := "FailString"
+		if e.Fold {
+			failFun = "FailStringFold"
+		}
+		return append(c.examine(intConst(len(str))),
 			&ast.IfStmt{
-				Cond: not(peglibCall(hasPrefixFun, input, stringConst(string(str)))),
-				Body: &ast.BlockStmt{List: onFailure()},
+				Cond: not(peglibCall(hasPrefixFun, input, stringConst(str))),
+				Body: &ast.BlockStmt{List: append(c.failWith(peglibCall(failFun, input, stringConst(str))), onFailure()...)},
 			},
 			consumeInput(intConst(len(str))),
-		}
+		)
 
 	case *CharacterClassTerminal:
-		if len(e.Selections) == 0 {
-			return []ast.Stmt{
-				consumeInput(intConst(1)),
-			}
-		}
-
-		unquoteChar := func(s jetpeg.Stringer) rune {
-			str := s.String()
-			switch str {
-			case `\-`:
-				return '-'
-			case `\0`:
-				return 0
-			}
-			char, _, _, err := strconv.UnquoteChar(str, 0)
-			if err != nil {
-				panic(err)
-			}
-			return char
-		}
-		var selections []rune
-		for _, sel := range e.Selections {
-			switch s := sel.(type) {
-			case *CharacterClassSingleCharacter:
-				selections = append(selections, unquoteChar(s.Char))
-			case *CharacterClassRange:
-				for i := unquoteChar(s.BeginChar); i <= unquoteChar(s.EndChar); i++ {
-					selections = append(selections, i)
-				}
-			}
-		}
-
-		cond := peglibCall("ContainsByte", stringConst(string(selections)), &ast.IndexExpr{X: input, Index: intConst(0)})
-		if !e.Inverted {
-			cond = not(cond)
-		}
-		return []ast.Stmt{
-			&ast.IfStmt{
-				Cond: cond,
-				Body: &ast.BlockStmt{List: onFailure()},
-			},
-			consumeInput(intConst(1)),
-		}
+		return c.compileCharClass(e, onFailure)
 
 	case *Sequence:
 		var stmts []ast.Stmt
 		outputCount := 0
 		for _, child := range e.Children {
 			stmts = append(stmts, c.compileExpr(child.(ParsingExpression), func() []ast.Stmt {
+				if outputCount == 0 {
+					return onFailure()
+				}
 				return append([]ast.Stmt{
 					exprStmt(peglibCall("Pop", intConst(outputCount))),
 				}, onFailure()...)
@@ -99,34 +69,46 @@ func (c *Context) compileExpr(expr ParsingExpression, onFailure func() []ast.Stm
 		}
 
 		choiceSuccessful := newDynamicLabel("choiceSuccessful")
-		beforeChoice := newIdent("beforeChoice")
-		stmts := []ast.Stmt{simpleDefine(beforeChoice, input)}
+		beforeChoice := c.newBacktrackPoint("beforeChoice")
+		dispatch := c.newChoiceDispatch(e)
+		stmts := beforeChoice.Save()
+		if dispatch != nil {
+			stmts = append(stmts, c.examine(intConst(1))...)
+		}
+		// no alternative can start with the next byte
+		dispatchFailure := func() []ast.Stmt { return append(c.fail(intConst(0)), onFailure()...) }
+		stmts = append(stmts, dispatch.Dispatch(0, dispatchFailure)...)
 		for i, theChild := range e.Children {
 			child := theChild.(ParsingExpression)
 			if i == len(e.Children)-1 {
-				stmts = append(stmts, &ast.BlockStmt{List: c.compileExpr(child, onFailure)})
+				stmts = append(stmts, dispatch.WithAlternativeLabel(i, &ast.BlockStmt{List: c.compileExpr(child, onFailure)}))
 				if c.hasOutput(e) && !c.hasOutput(child) {
 					stmts = append(stmts, exprStmt(peglibCall("PushEmpty")))
 				}
 				break
 			}
 			nextChoice := newDynamicLabel("nextChoice")
-			stmts = append(stmts, &ast.BlockStmt{List: c.compileExpr(child, nextChoice.GotoSlice)})
+			stmts = append(stmts, dispatch.WithAlternativeLabel(i, &ast.BlockStmt{List: c.compileExpr(child, nextChoice.GotoSlice)}))
 			if c.hasOutput(e) && !c.hasOutput(child) {
 				stmts = append(stmts, exprStmt(peglibCall("PushEmpty")))
 			}
 			stmts = append(stmts,
 				choiceSuccessful.Goto(),
 				nextChoice.WithLabel(nil),
-				simpleAssign(input, beforeChoice),
 			)
+			stmts = append(stmts, beforeChoice.Restore()...)
+			stmts = append(stmts, dispatch.Dispatch(i+1, dispatchFailure)...)
 		}
 		stmts = append(stmts, choiceSuccessful.WithLabel(nil))
 		return stmts
 
 	case *Repetition:
+		if class, ok := charClassLoop(e); ok {
+			return c.compileCharClassLoop(e, class, onFailure)
+		}
+
 		repetitionLabel := newDynamicLabel("repetition")
-		beforeRepetition := newIdent("beforeRepetition")
+		beforeRepetition := c.newBacktrackPoint("beforeRepetition")
 		var first *ast.Ident
 		var forInit, forPost ast.Stmt
 		if e.AtLeastOnce || e.GlueExpression != nil {
@@ -135,20 +117,15 @@ func (c *Context) compileExpr(expr ParsingExpression, onFailure func() []ast.Stm
 			forPost = simpleAssign(first, ast.NewIdent("false"))
 		}
 		breakLoop := func() []ast.Stmt {
+			var stmts []ast.Stmt
 			if e.AtLeastOnce {
-				return []ast.Stmt{
-					&ast.IfStmt{
-						Cond: first,
-						Body: &ast.BlockStmt{List: onFailure()},
-					},
-					simpleAssign(input, beforeRepetition),
-					repetitionLabel.Break(),
-				}
-			}
-			return []ast.Stmt{
-				simpleAssign(input, beforeRepetition),
-				repetitionLabel.Break(),
+				stmts = append(stmts, &ast.IfStmt{
+					Cond: first,
+					Body: &ast.BlockStmt{List: onFailure()},
+				})
 			}
+			stmts = append(stmts, beforeRepetition.Restore()...)
+			return append(stmts, repetitionLabel.Break())
 		}
 
 		var body []ast.Stmt
@@ -162,64 +139,81 @@ func (c *Context) compileExpr(expr ParsingExpression, onFailure func() []ast.Stm
 				Body: &ast.BlockStmt{List: glueBody},
 			})
 		}
+		array := c.newArrayBuilder(e)
 		body = append(body, c.compileExpr(e.Child, breakLoop)...)
 		if c.hasOutput(e.Child) {
-			body = append(body, exprStmt(peglibCall("AppendToArray")))
+			body = append(body, array.Append())
+		}
+		if c.loopsWithoutProgress(e) {
+			// stop after an iteration that did not consume input
+			body = append(body, &ast.IfStmt{
+				Cond: noProgress(beforeRepetition),
+				Body: &ast.BlockStmt{List: []ast.Stmt{repetitionLabel.Break()}},
+			})
 		}
 		if repetitionLabel.Used {
-			body = append([]ast.Stmt{simpleDefine(beforeRepetition, input)}, body...)
+			body = append(beforeRepetition.Save(), body...)
 		}
 
-		var stmts []ast.Stmt
-		if c.hasOutput(e) {
-			stmts = append(stmts, exprStmt(peglibCall("PushArray")))
-		}
+		stmts := array.Begin()
 		stmts = append(stmts, repetitionLabel.WithLabel(&ast.ForStmt{
 			Init: forInit,
 			Post: forPost,
 			Body: &ast.BlockStmt{List: body},
 		}))
-		return stmts
+		return append(stmts, array.End()...)
 
 	case *Until:
 		untilLabel := newDynamicLabel("until")
 		checkFailed := newDynamicLabel("checkFailed")
-		beforeCheck := newIdent("beforeCheck")
+		beforeCheck := c.newBacktrackPoint("beforeCheck")
 
-		body := []ast.Stmt{simpleDefine(beforeCheck, input)}
+		array := c.newArrayBuilder(e)
+		body := beforeCheck.Save()
 		body = append(body, &ast.BlockStmt{List: c.compileExpr(e.UntilExpression, checkFailed.GotoSlice)})
 		if c.hasOutput(e.UntilExpression) {
-			body = append(body, exprStmt(peglibCall("AppendToArray")))
+			body = append(body, array.Append())
 		}
-		body = append(body, untilLabel.Break(), checkFailed.WithLabel(simpleAssign(input, beforeCheck)))
-		body = append(body, c.compileExpr(e.Child, onFailure)...)
+		restore := beforeCheck.Restore()
+		body = append(body, untilLabel.Break(), checkFailed.WithLabel(restore[0]))
+		body = append(body, restore[1:]...)
+		untilFailed := func() []ast.Stmt {
+			return append(array.Discard(), onFailure()...)
+		}
+		body = append(body, c.compileExpr(e.Child, untilFailed)...)
 		if c.hasOutput(e.Child) {
-			body = append(body, exprStmt(peglibCall("AppendToArray")))
+			body = append(body, array.Append())
 		}
-
-		var stmts []ast.Stmt
-		if c.hasOutput(e) {
-			stmts = append(stmts, exprStmt(peglibCall("PushArray")))
+		if c.loopsWithoutProgress(e) {
+			// the until expression will never match if the input stays the same
+			body = append(body, &ast.IfStmt{
+				Cond: noProgress(beforeCheck),
+				Body: &ast.BlockStmt{List: untilFailed()},
+			})
 		}
+
+		stmts := array.Begin()
 		stmts = append(stmts, untilLabel.WithLabel(&ast.ForStmt{Body: &ast.BlockStmt{List: body}}))
-		return stmts
+		return append(stmts, array.End()...)
 
 	case *PositiveLookahead:
-		beforeLookahead := newIdent("beforeLookahead")
+		beforeLookahead := c.newBacktrackPoint("beforeLookahead")
 		var stmts []ast.Stmt
-		stmts = append(stmts, simpleDefine(beforeLookahead, input))
+		stmts = append(stmts, beforeLookahead.Save()...)
 		stmts = append(stmts, c.compileExpr(e.Child, onFailure)...)
-		stmts = append(stmts, simpleAssign(input, beforeLookahead))
+		stmts = append(stmts, beforeLookahead.Restore()...)
 		return stmts
 
 	case *NegativeLookahead:
 		lookaheadSuccessful := newDynamicLabel("lookaheadSuccessful")
-		beforeLookahead := newIdent("beforeLookahead")
+		beforeLookahead := c.newBacktrackPoint("beforeLookahead")
 		var stmts []ast.Stmt
-		stmts = append(stmts, simpleDefine(beforeLookahead, input))
+		stmts = append(stmts, beforeLookahead.Save()...)
 		stmts = append(stmts, c.compileExpr(e.Child, lookaheadSuccessful.GotoSlice)...)
 		stmts = append(stmts, onFailure()...)
-		stmts = append(stmts, lookaheadSuccessful.WithLabel(simpleAssign(input, beforeLookahead)))
+		restore := beforeLookahead.Restore()
+		stmts = append(stmts, lookaheadSuccessful.WithLabel(restore[0]))
+		stmts = append(stmts, restore[1:]...)
 		return stmts
 
 	case *RuleCall:
@@ -237,10 +231,24 @@ func (c *Context) compileExpr(expr ParsingExpression, onFailure func() []ast.Stm
 	case *ParenthesizedExpression:
 		return c.compileExpr(e.Child, onFailure)
 
+	case *OperatorPrecedence:
+		fun := newIdent("precedence")
+		c.helperDecls = append(c.helperDecls, c.compilePrecedence(fun, e))
+		return []ast.Stmt{
+			simpleAssign(input, &ast.CallExpr{Fun: fun, Args: []ast.Expr{input, intConst(0)}}),
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: input, Op: token.EQL, Y: ast.NewIdent("nil")},
+				Body: &ast.BlockStmt{List: onFailure()},
+			},
+		}
+
 	case *EmptyParsingExpression:
 		return nil
 
 	case *Label:
+		if !c.buildsValues() {
+			return c.compileLabelText(e, onFailure)
+		}
 		stmts := c.compileExpr(e.Child, onFailure)
 		nameIsAt := e.Name.String() == "@"
 		childHasOutput := c.hasOutput(e.Child)
@@ -254,6 +262,9 @@ func (c *Context) compileExpr(expr ParsingExpression, onFailure func() []ast.Stm
 			stmts = append([]ast.Stmt{simpleDefine(labelStart, input)}, stmts...)
 			stmts = append(stmts, exprStmt(peglibCall("PushInputRange", labelStart, input)))
 		}
+		if c.labelVars[e.Name.String()] && !e.IsLocal {
+			stmts = append(stmts, simpleAssign(ast.NewIdent(e.Name.String()), peglibCall("Top")))
+		}
 
 		switch {
 		case e.IsLocal:
@@ -266,10 +277,74 @@ func (c *Context) compileExpr(expr ParsingExpression, onFailure func() []ast.Stm
 
 		return stmts
 
+	case *ObjectCreator:
+		stmts := c.compileExpr(e.Child, onFailure)
+		if !c.buildsValues() {
+			return stmts
+		}
+		if !c.hasOutput(e.Child) {
+			stmts = append(stmts, exprStmt(peglibCall("PushEmpty")))
+		}
+		if e.Data != nil {
+			// the data replaces the value of the child, which it refers to
+			stmts = append(stmts, exprStmt(peglibCall("SetAsSource")))
+			stmts = append(stmts, compileData(e.Data)...)
+			stmts = append(stmts, exprStmt(peglibCall("ReplaceSource")))
+		}
+		return append(stmts, exprStmt(peglibCall("MakeObject", stringConst(e.ClassName.String()))))
+
+	case *PositivePredicate:
+		return []ast.Stmt{
+			&ast.IfStmt{
+				Cond: not(&ast.ParenExpr{X: c.goCode(e.Code, e.Pos)}),
+				Body: &ast.BlockStmt{List: onFailure()},
+			},
+		}
+
+	case *NegativePredicate:
+		return []ast.Stmt{
+			&ast.IfStmt{
+				Cond: &ast.ParenExpr{X: c.goCode(e.Code, e.Pos)},
+				Body: &ast.BlockStmt{List: onFailure()},
+			},
+		}
+
+	case *Action:
+		return []ast.Stmt{exprStmt(c.goCode(e.Code, e.Pos))}
+
 	case *TrueFunction:
+		if !c.buildsValues() {
+			return nil
+		}
 		return []ast.Stmt{exprStmt(peglibCall("PushTrue"))}
 
+	case *IndentFunction:
+		return []ast.Stmt{
+			&ast.IfStmt{
+				Cond: not(peglibCall("Indent", input)),
+				Body: &ast.BlockStmt{List: onFailure()},
+			},
+		}
+
+	case *SamedentFunction:
+		return []ast.Stmt{
+			simpleAssign(input, peglibCall("Samedent", input)),
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: input, Op: token.EQL, Y: ast.NewIdent("nil")},
+				Body: &ast.BlockStmt{List: onFailure()},
+			},
+		}
+
+	case *DedentFunction:
+		return []ast.Stmt{exprStmt(peglibCall("Dedent"))}
+
+	case *CommitFunction:
+		return []ast.Stmt{exprStmt(peglibCall("Commit", input))}
+
 	case *FalseFunction:
+		if !c.buildsValues() {
+			return nil
+		}
 		return []ast.Stmt{exprStmt(peglibCall("PushFalse"))}
 
 	default:
@@ -278,6 +353,9 @@ func (c *Context) compileExpr(expr ParsingExpression, onFailure func() []ast.Stm
 }
 
 func (c *Context) hasOutput(expr ParsingExpression) bool {
+	if !c.buildsValues() {
+		return false
+	}
 	switch e := expr.(type) {
 	case *Rule:
 		if !e.HasOutputCalculated {
@@ -318,6 +396,9 @@ func (c *Context) hasOutput(expr ParsingExpression) bool {
 	case *Label:
 		return !e.IsLocal
 
+	case *ObjectCreator, *OperatorPrecedence:
+		return true
+
 	case *TrueFunction, *FalseFunction:
 		return true
 
@@ -326,6 +407,261 @@ func (c *Context) hasOutput(expr ParsingExpression) bool {
 	}
 }
 
+// compileData returns the statements pushing the data of an object creator.
+// Labels refer to the values of the source, see peglib.SetAsSource.
+func compileData(data interface{}) []ast.Stmt {
+	switch d := data.(type) {
+	case *StringData:
+		str, _ := unescape(d.String.String()) // valid, see Context.check
+		return []ast.Stmt{exprStmt(peglibCall("PushString", stringConst(str)))}
+
+	case *BooleanData:
+		if d.Value {
+			return []ast.Stmt{exprStmt(peglibCall("PushTrue"))}
+		}
+		return []ast.Stmt{exprStmt(peglibCall("PushFalse"))}
+
+	case *HashData:
+		var stmts []ast.Stmt
+		for _, entry := range d.Entries {
+			entry := entry.(*HashDataEntry)
+			stmts = append(stmts, compileData(entry.Data)...)
+			stmts = append(stmts, exprStmt(peglibCall("MakeLabel", stringConst(entry.Label.String()))))
+		}
+		switch len(d.Entries) {
+		case 0:
+			stmts = append(stmts, exprStmt(peglibCall("PushEmpty")))
+		case 1:
+		default:
+			stmts = append(stmts, exprStmt(peglibCall("MergeLabels", intConst(len(d.Entries)))))
+		}
+		return stmts
+
+	case *ArrayData:
+		var stmts []ast.Stmt
+		for _, entry := range d.Entries {
+			stmts = append(stmts, compileData(entry.(*ArrayDataEntry).Data)...)
+		}
+		return append(stmts, exprStmt(peglibCall("MakeArray", intConst(len(d.Entries)))))
+
+	case *ObjectData:
+		return append(compileData(d.Data), exprStmt(peglibCall("MakeObject", stringConst(d.ClassName.String()))))
+
+	case *LabelData:
+		return []ast.Stmt{exprStmt(peglibCall("ReadFromSource", stringConst(d.Name.String())))}
+
+	default:
+		panic("compileData not implemented for given type")
+	}
+}
+
+// examine returns a statement recording that n bytes of the input are
+// examined, if rules are memoized or streaming.
+func (c *Context) examine(n ast.Expr) []ast.Stmt {
+	if !c.Options.Memoize && !c.Options.Streaming {
+		return nil
+	}
+	return []ast.Stmt{exprStmt(peglibCall("Examine", input, n))}
+}
+
+// fail returns the statements recording that a terminal failed at the byte n
+// of the input, if limits are enforced, see peglib.Fail.
+func (c *Context) fail(n ast.Expr) []ast.Stmt {
+	return c.failWith(peglibCall("Fail", input, n))
+}
+
+// failWith is like fail for a call recording the failure.
+func (c *Context) failWith(call ast.Expr) []ast.Stmt {
+	if !c.Options.Limits {
+		return nil
+	}
+	return []ast.Stmt{exprStmt(call)}
+}
+
+// enter returns the statements starting a rule or precedence function, which
+// count the invocation if limits are enforced.
+func (c *Context) enter() []ast.Stmt {
+	if !c.Options.Limits {
+		return nil
+	}
+	return []ast.Stmt{exprStmt(peglibCall("Enter", input))}
+}
+
+// leave wraps a result returned by a rule or precedence function, which ends
+// the invocation if limits are enforced.
+func (c *Context) leave(result ast.Expr) ast.Expr {
+	if !c.Options.Limits {
+		return result
+	}
+	return peglibCall("Leave", result)
+}
+
+// buildsValues reports whether expressions produce values on the output
+// stack. A concrete syntax tree is built by the rules instead.
+func (c *Context) buildsValues() bool {
+	return c.Options.Output == OutputValues || c.Options.Output == OutputLight
+}
+
+// compileLabelText compiles a label without using the output stack. If Go
+// code refers to the label, its variable is set to the matched input range.
+func (c *Context) compileLabelText(e *Label, onFailure func() []ast.Stmt) []ast.Stmt {
+	stmts := c.compileExpr(e.Child, onFailure)
+	if !c.labelVars[e.Name.String()] || e.IsLocal {
+		return stmts
+	}
+	labelStart := newIdent("labelStart")
+	stmts = append([]ast.Stmt{simpleDefine(labelStart, input)}, stmts...)
+	return append(stmts, simpleAssign(ast.NewIdent(e.Name.String()), &ast.CallExpr{
+		Fun: &ast.SelectorExpr{X: ast.NewIdent("peglib"), Sel: ast.NewIdent("InputRange")},
+		Args: []ast.Expr{&ast.SliceExpr{
+			X:    labelStart,
+			High: &ast.BinaryExpr{X: &ast.CallExpr{Fun: ast.NewIdent("len"), Args: []ast.Expr{labelStart}}, Op: token.SUB, Y: &ast.CallExpr{Fun: ast.NewIdent("len"), Args: []ast.Expr{input}}},
+		}},
+	}))
+}
+
+// arrayBuilder emits the calls building the array output of a repetition.
+// With OutputLight the elements stay on the output stack and are counted in
+// a local variable until the array is made at the end.
+type arrayBuilder struct {
+	hasOutput bool
+	count     *ast.Ident
+}
+
+func (c *Context) newArrayBuilder(e ParsingExpression) *arrayBuilder {
+	a := &arrayBuilder{hasOutput: c.hasOutput(e)}
+	if a.hasOutput && c.Options.Output == OutputLight {
+		a.count = newIdent("count")
+	}
+	return a
+}
+
+func (a *arrayBuilder) Begin() []ast.Stmt {
+	switch {
+	case !a.hasOutput:
+		return nil
+	case a.count != nil:
+		return []ast.Stmt{simpleDefine(a.count, intConst(0))}
+	default:
+		return []ast.Stmt{exprStmt(peglibCall("PushArray"))}
+	}
+}
+
+func (a *arrayBuilder) Append() ast.Stmt {
+	if a.count != nil {
+		return &ast.IncDecStmt{X: a.count, Tok: token.INC}
+	}
+	return exprStmt(peglibCall("AppendToArray"))
+}
+
+// Discard removes the unfinished array from the output stack.
+func (a *arrayBuilder) Discard() []ast.Stmt {
+	switch {
+	case !a.hasOutput:
+		return nil
+	case a.count != nil:
+		return []ast.Stmt{exprStmt(peglibCall("Pop", a.count))}
+	default:
+		return []ast.Stmt{exprStmt(peglibCall("Pop", intConst(1)))}
+	}
+}
+
+func (a *arrayBuilder) End() []ast.Stmt {
+	if a.count == nil {
+		return nil
+	}
+	return []ast.Stmt{exprStmt(peglibCall("MakeArray", a.count))}
+}
+
+// goCode returns Go code from the grammar for verbatim inclusion in the
+// generated source, preceded by a line directive if the position is known.
+func (c *Context) goCode(code fmt.Stringer, pos token.Position) ast.Expr {
+	text := code.String()
+	if c.Options.Filename != "" && pos.IsValid() {
+		text = fmt.Sprintf("/*line %s:%d:%d*/", pos.Filename, pos.Line, pos.Column) + text
+	}
+	return ast.NewIdent(text)
+}
+
+// codePrologue declares a local variable for each label in expr, so that Go
+// code in the rule can refer to labelled values by name, and a local variable
+// state holding peglib.UserState if a state type is configured. The rule
+// panics with a clear message if UserState is not of that type, e.g. nil.
+func (c *Context) codePrologue(expr ParsingExpression) []ast.Stmt {
+	c.labelVars = make(map[string]bool)
+	var names []*ast.Ident
+	var stmts []ast.Stmt
+	if c.Options.StateType != "" {
+		state, ok := ast.NewIdent("state"), newIdent("stateOK")
+		stmts = append(stmts,
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{state, ok},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.TypeAssertExpr{X: &ast.SelectorExpr{X: ast.NewIdent("peglib"), Sel: ast.NewIdent("UserState")}, Type: ast.NewIdent(c.Options.StateType)}},
+			},
+			&ast.IfStmt{
+				Cond: not(ok),
+				Body: &ast.BlockStmt{List: []ast.Stmt{exprStmt(&ast.CallExpr{
+					Fun:  ast.NewIdent("panic"),
+					Args: []ast.Expr{stringConst("peglib.UserState must be set to a value of type " + c.Options.StateType)},
+				})}},
+			},
+			simpleAssign(ast.NewIdent("_"), state),
+		)
+	}
+	walkExpr(expr, func(expr ParsingExpression) {
+		if l, ok := expr.(*Label); ok && !l.IsLocal && l.Name.String() != "@" && !c.labelVars[l.Name.String()] {
+			c.labelVars[l.Name.String()] = true
+			names = append(names, ast.NewIdent(l.Name.String()))
+			stmts = append(stmts, simpleAssign(ast.NewIdent("_"), ast.NewIdent(l.Name.String())))
+		}
+	})
+	if len(names) == 0 {
+		return stmts
+	}
+	decl := &ast.DeclStmt{Decl: &ast.GenDecl{
+		Tok:   token.VAR,
+		Specs: []ast.Spec{&ast.ValueSpec{Names: names, Type: ast.NewIdent("interface{}")}},
+	}}
+	return append([]ast.Stmt{decl}, stmts...)
+}
+
+// reservedName reports whether name cannot be the variable of a label in a
+// rule with Go code, because the generated code of the rule uses it.
+func (c *Context) reservedName(name string) bool {
+	switch name {
+	case "_", "input", "peglib", "byte", "false", "int", "len", "nil", "panic", "true":
+		return true
+	case "state":
+		return c.Options.StateType != ""
+	}
+	return token.IsKeyword(name)
+}
+
+func containsCode(expr ParsingExpression) bool {
+	found := false
+	walkExpr(expr, func(expr ParsingExpression) {
+		switch expr.(type) {
+		case *PositivePredicate, *NegativePredicate, *Action:
+			found = true
+		}
+	})
+	return found
+}
+
+// modifiesState reports whether expr contains Go code or indentation
+// functions, which require the parser state to be restored on backtracking.
+func modifiesState(expr ParsingExpression) bool {
+	found := false
+	walkExpr(expr, func(expr ParsingExpression) {
+		switch expr.(type) {
+		case *PositivePredicate, *NegativePredicate, *Action, *IndentFunction, *DedentFunction:
+			found = true
+		}
+	})
+	return found
+}
+
 var input = ast.NewIdent("input")
 
 func simpleAssign(lhs, rhs ast.Expr) ast.Stmt {
@@ -370,6 +706,59 @@ func peglibCall(fun string, args ...ast.Expr) ast.Expr {
 	}
 }
 
+// backtrackPoint is a position the generated code may return to. Besides the
+// input it saves a snapshot of the parser state if the grammar can modify it,
+// and the height of the output stack when building a concrete syntax tree.
+type backtrackPoint struct {
+	input *ast.Ident
+	state *ast.Ident
+	mark  *ast.Ident
+}
+
+func (c *Context) newBacktrackPoint(prefix string) *backtrackPoint {
+	p := &backtrackPoint{input: newIdent(prefix)}
+	suffix := strings.ToUpper(prefix[:1]) + prefix[1:]
+	if c.usesState {
+		p.state = newIdent("state" + suffix)
+	}
+	if c.Options.Output == OutputCST {
+		p.mark = newIdent("mark" + suffix)
+	}
+	return p
+}
+
+func (p *backtrackPoint) Save() []ast.Stmt {
+	stmts := []ast.Stmt{simpleDefine(p.input, input)}
+	if p.state != nil {
+		stmts = append(stmts, simpleDefine(p.state, peglibCall("SaveState")))
+	}
+	if p.mark != nil {
+		stmts = append(stmts, simpleDefine(p.mark, peglibCall("Mark")))
+	}
+	return stmts
+}
+
+func (p *backtrackPoint) Restore() []ast.Stmt {
+	stmts := []ast.Stmt{simpleAssign(input, p.input)}
+	if p.state != nil {
+		stmts = append(stmts, exprStmt(peglibCall("RestoreState", p.state)))
+	}
+	if p.mark != nil {
+		stmts = append(stmts, exprStmt(peglibCall("Rewind", p.mark)))
+	}
+	return stmts
+}
+
+// noProgress returns a condition that is true if no input has been consumed
+// since p was saved.
+func noProgress(p *backtrackPoint) ast.Expr {
+	return &ast.BinaryExpr{
+		X:  &ast.CallExpr{Fun: ast.NewIdent("len"), Args: []ast.Expr{input}},
+		Op: token.EQL,
+		Y:  &ast.CallExpr{Fun: ast.NewIdent("len"), Args: []ast.Expr{p.input}},
+	}
+}
+
 type dynamicLabel struct {
 	Ident *ast.Ident
 	Used  bool
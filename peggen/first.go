@@ -0,0 +1,216 @@
+package peggen
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+)
+
+var allBytes = byteSet{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+
+// computeFirst sets Rule.First for all rules. It must be called after
+// computeNullable. The sets only grow, so they are updated until nothing
+// changes anymore.
+func (c *Context) computeFirst() {
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range c.ruleList {
+			first := c.first(rule.Child)
+			first.union(&rule.First)
+			if first != rule.First {
+				rule.First = first
+				changed = true
+			}
+		}
+	}
+}
+
+// first returns the set of bytes that a match of expr consuming input can
+// start with.
+func (c *Context) first(expr ParsingExpression) byteSet {
+	switch e := expr.(type) {
+	case *Rule:
+		return e.First
+
+	case *RuleCall:
+		if rule, ok := c.Rules[e.Name.String()]; ok {
+			return rule.First
+		}
+		return byteSet{}
+
+	case *StringTerminal:
+		sets, _ := literalSets(e)
+		if len(sets) == 0 {
+			return byteSet{}
+		}
+		return sets[0]
+
+	case *CharacterClassTerminal:
+		set, _ := charClassSet(e) // invalid escapes are reported by Check
+		return set
+
+	case *Sequence:
+		var set byteSet
+		for _, child := range e.Children {
+			childFirst := c.first(child.(ParsingExpression))
+			set.union(&childFirst)
+			if !c.nullable(child.(ParsingExpression)) {
+				break
+			}
+		}
+		return set
+
+	case *Choice:
+		var set byteSet
+		for _, child := range e.Children {
+			childFirst := c.first(child.(ParsingExpression))
+			set.union(&childFirst)
+		}
+		return set
+
+	case *Repetition:
+		if c.nullable(e.Child) {
+			return allBytes
+		}
+		return c.first(e.Child)
+
+	case *Until:
+		if c.nullable(e.Child) {
+			return allBytes
+		}
+		set := c.first(e.Child)
+		untilFirst := c.first(e.UntilExpression)
+		set.union(&untilFirst)
+		return set
+
+	case *ParenthesizedExpression:
+		return c.first(e.Child)
+
+	case *Label:
+		return c.first(e.Child)
+
+	case *ObjectCreator:
+		return c.first(e.Child)
+
+	case *EmptyParsingExpression, *PositiveLookahead, *NegativeLookahead, *PositivePredicate, *NegativePredicate, *Action,
+		*TrueFunction, *FalseFunction, *IndentFunction, *DedentFunction, *CommitFunction:
+		return byteSet{}
+
+	default:
+		return allBytes
+	}
+}
+
+// viable returns the set of bytes for which expr may succeed if input starts
+// with them.
+func (c *Context) viable(expr ParsingExpression) byteSet {
+	if c.nullable(expr) {
+		return allBytes
+	}
+	return c.first(expr)
+}
+
+// choiceDispatch decides which alternatives of a choice to try based on the
+// first byte of the input.
+type choiceDispatch struct {
+	viable       []byteSet
+	alternatives []*dynamicLabel
+}
+
+// newChoiceDispatch returns nil if every alternative of e is viable for all
+// bytes, so that dispatching would not skip any alternatives.
+func (c *Context) newChoiceDispatch(e *Choice) *choiceDispatch {
+	d := &choiceDispatch{}
+	useful := false
+	for _, child := range e.Children {
+		viable := c.viable(child.(ParsingExpression))
+		if viable != allBytes {
+			useful = true
+		}
+		d.viable = append(d.viable, viable)
+		d.alternatives = append(d.alternatives, newDynamicLabel("alternative"))
+	}
+	if !useful {
+		return nil
+	}
+	return d
+}
+
+// WithAlternativeLabel labels stmt as alternative i if it is jumped to.
+func (d *choiceDispatch) WithAlternativeLabel(i int, stmt ast.Stmt) ast.Stmt {
+	if d == nil {
+		return stmt
+	}
+	return d.alternatives[i].WithLabel(stmt)
+}
+
+// Dispatch returns a switch on the first byte of the input which jumps to the
+// first viable alternative from index from on, or runs onFailure if there is
+// none. Falling through the switch continues with alternative from.
+func (d *choiceDispatch) Dispatch(from int, onFailure func() []ast.Stmt) []ast.Stmt {
+	if d == nil {
+		return nil
+	}
+
+	var targets []int // in order of appearance
+	bytes := make(map[int][]byte)
+	for b := 0; b < 256; b++ {
+		target := -1
+		for i := from; i < len(d.viable); i++ {
+			if d.viable[i].contains(byte(b)) {
+				target = i
+				break
+			}
+		}
+		if _, ok := bytes[target]; !ok {
+			targets = append(targets, target)
+		}
+		bytes[target] = append(bytes[target], byte(b))
+	}
+	if len(targets) == 1 && targets[0] == from {
+		return nil
+	}
+
+	defaultTarget := targets[0]
+	for _, target := range targets {
+		if len(bytes[target]) > len(bytes[defaultTarget]) {
+			defaultTarget = target
+		}
+	}
+	body := func(target int) []ast.Stmt {
+		switch target {
+		case from:
+			return nil
+		case -1:
+			return onFailure()
+		default:
+			return []ast.Stmt{d.alternatives[target].Goto()}
+		}
+	}
+
+	var clauses []ast.Stmt
+	for _, target := range targets {
+		if target == defaultTarget {
+			continue
+		}
+		var list []ast.Expr
+		for _, b := range bytes[target] {
+			list = append(list, byteConst(b))
+		}
+		clauses = append(clauses, &ast.CaseClause{List: list, Body: body(target)})
+	}
+	if defaultTarget != from {
+		clauses = append(clauses, &ast.CaseClause{Body: body(defaultTarget)})
+	}
+	return []ast.Stmt{&ast.SwitchStmt{
+		Tag:  &ast.IndexExpr{X: input, Index: intConst(0)},
+		Body: &ast.BlockStmt{List: clauses},
+	}}
+}
+
+func byteConst(b byte) ast.Expr {
+	if b >= ' ' && b <= '~' {
+		return &ast.BasicLit{Kind: token.CHAR, Value: strconv.QuoteRune(rune(b))}
+	}
+	return intConst(int(b))
+}
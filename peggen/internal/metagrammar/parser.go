@@ -0,0 +1,3081 @@
+// Code generated by peg generate from metagrammar.peg. DO NOT EDIT.
+
+package metagrammar
+
+import "github.com/neelance/peg/peglib"
+
+func Grammar(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice1 := input
+	{
+		input = ws(input)
+		if input == nil {
+			goto nextChoice1
+		}
+	}
+	goto choiceSuccessful1
+nextChoice1:
+	;
+	input = beforeChoice1
+	{
+	}
+choiceSuccessful1:
+	;
+	peglib.PushArray()
+repetition1:
+	for {
+		beforeRepetition1 := input
+		if !peglib.HasPrefix(input, "rule") {
+			peglib.FailString(input, "rule")
+			input = beforeRepetition1
+			break repetition1
+		}
+		input = input[4:]
+		input = ws(input)
+		if input == nil {
+			input = beforeRepetition1
+			break repetition1
+		}
+		labelStart1 := input
+		peglib.PushInputRange(labelStart1, input)
+		peglib.MakeLabel("Pos")
+		input = ruleName(input)
+		if input == nil {
+			peglib.Pop(1)
+			input = beforeRepetition1
+			break repetition1
+		}
+		peglib.MakeLabel("Name")
+		beforeChoice2 := input
+		switch input[0] {
+		case '[':
+		default:
+			goto alternative4
+		}
+		{
+			if !peglib.HasPrefix(input, "[") {
+				peglib.FailString(input, "[")
+				goto nextChoice2
+			}
+			input = input[1:]
+			peglib.PushArray()
+		repetition2:
+			for first1 := true; ; first1 = false {
+				beforeRepetition2 := input
+				if !first1 {
+					if !peglib.HasPrefix(input, ",") {
+						peglib.FailString(input, ",")
+						input = beforeRepetition2
+						break repetition2
+					}
+					input = input[1:]
+					input = ws(input)
+					if input == nil {
+						input = beforeRepetition2
+						break repetition2
+					}
+				}
+				input = localValue(input)
+				if input == nil {
+					input = beforeRepetition2
+					break repetition2
+				}
+				peglib.AppendToArray()
+			}
+			if !peglib.HasPrefix(input, "]") {
+				peglib.FailString(input, "]")
+				peglib.Pop(1)
+				goto nextChoice2
+			}
+			input = input[1:]
+		}
+		goto choiceSuccessful2
+	nextChoice2:
+		;
+		input = beforeChoice2
+	alternative4:
+		{
+		}
+		peglib.PushEmpty()
+	choiceSuccessful2:
+		;
+		peglib.MakeLabel("Parameters")
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(3)
+			input = beforeRepetition1
+			break repetition1
+		}
+		beforeChoice3 := input
+		{
+			input = ws(input)
+			if input == nil {
+				goto nextChoice3
+			}
+		}
+		goto choiceSuccessful3
+	nextChoice3:
+		;
+		input = beforeChoice3
+		{
+		}
+	choiceSuccessful3:
+		;
+		input = expression(input)
+		if input == nil {
+			peglib.Pop(3)
+			input = beforeRepetition1
+			break repetition1
+		}
+		peglib.MakeLabel("Child")
+		peglib.MakeObject("Rule")
+		peglib.MakeLabel("Child")
+		if !peglib.HasPrefix(input, "end") {
+			peglib.FailString(input, "end")
+			peglib.Pop(4)
+			input = beforeRepetition1
+			break repetition1
+		}
+		input = input[3:]
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(4)
+			input = beforeRepetition1
+			break repetition1
+		}
+		peglib.MergeLabels(4)
+		peglib.AppendToArray()
+	}
+	peglib.MakeLabel("Rules")
+	labelStart2 := input
+	peglib.PushInputRange(labelStart2, input)
+	peglib.MakeLabel("End")
+	peglib.MergeLabels(2)
+	return peglib.Leave(input)
+}
+func expression(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice4 := input
+	switch input[0] {
+	case '/':
+	default:
+		goto alternative8
+	}
+	{
+		if !peglib.HasPrefix(input, "/") {
+			peglib.FailString(input, "/")
+			goto nextChoice4
+		}
+		input = input[1:]
+		input = ws(input)
+		if input == nil {
+			goto nextChoice4
+		}
+	}
+	goto choiceSuccessful4
+nextChoice4:
+	;
+	input = beforeChoice4
+alternative8:
+	{
+	}
+choiceSuccessful4:
+	;
+	input = choice(input)
+	if input == nil {
+		return peglib.Leave(nil)
+	}
+	return peglib.Leave(input)
+}
+func choice(input []byte) []byte {
+	peglib.Enter(input)
+	peglib.PushArray()
+repetition3:
+	for first2 := true; ; first2 = false {
+		beforeRepetition3 := input
+		if !first2 {
+			if !peglib.HasPrefix(input, "/") {
+				peglib.FailString(input, "/")
+				if first2 {
+					return peglib.Leave(nil)
+				}
+				input = beforeRepetition3
+				break repetition3
+			}
+			input = input[1:]
+			input = ws(input)
+			if input == nil {
+				if first2 {
+					return peglib.Leave(nil)
+				}
+				input = beforeRepetition3
+				break repetition3
+			}
+		}
+		input = creator(input)
+		if input == nil {
+			if first2 {
+				return peglib.Leave(nil)
+			}
+			input = beforeRepetition3
+			break repetition3
+		}
+		peglib.AppendToArray()
+	}
+	peglib.MakeLabel("Children")
+	peglib.MakeObject("Choice")
+	return peglib.Leave(input)
+}
+func creator(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice5 := input
+	switch input[0] {
+	case '!', '"', '$', '%', '&', '\'', '(', '.', ':', '@', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		input = sequence(input)
+		if input == nil {
+			goto nextChoice5
+		}
+		peglib.MakeLabel("Child")
+		if !peglib.HasPrefix(input, "<") {
+			peglib.FailString(input, "<")
+			peglib.Pop(1)
+			goto nextChoice5
+		}
+		input = input[1:]
+		labelStart3 := input
+	repetition4:
+		for first3 := true; ; first3 = false {
+			beforeRepetition4 := input
+			beforeChoice6 := input
+			switch input[0] {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				goto alternative12
+			case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+			default:
+				peglib.Fail(input, 0)
+				if first3 {
+					peglib.Pop(1)
+					goto nextChoice5
+				}
+				input = beforeRepetition4
+				break repetition4
+			}
+			{
+				if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+					peglib.Fail(input, 0)
+					goto nextChoice6
+				}
+				input = input[1:]
+			}
+			goto choiceSuccessful6
+		nextChoice6:
+			;
+			input = beforeChoice6
+			switch input[0] {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			default:
+				peglib.Fail(input, 0)
+				if first3 {
+					peglib.Pop(1)
+					goto nextChoice5
+				}
+				input = beforeRepetition4
+				break repetition4
+			}
+		alternative12:
+			{
+				if input[0] < '0' || input[0] > '9' {
+					peglib.Fail(input, 0)
+					if first3 {
+						peglib.Pop(1)
+						goto nextChoice5
+					}
+					input = beforeRepetition4
+					break repetition4
+				}
+				input = input[1:]
+			}
+		choiceSuccessful6:
+		}
+		peglib.PushInputRange(labelStart3, input)
+		peglib.MakeLabel("ClassName")
+		beforeChoice7 := input
+		switch input[0] {
+		case 9, 10, 13, ' ', '#', '\'', '<', '@', '[', 'f', 't', '{':
+		default:
+			goto alternative14
+		}
+		{
+			input = ws(input)
+			if input == nil {
+				goto nextChoice7
+			}
+			input = data(input)
+			if input == nil {
+				goto nextChoice7
+			}
+			peglib.MakeLabel("data")
+		}
+		goto choiceSuccessful7
+	nextChoice7:
+		;
+		input = beforeChoice7
+	alternative14:
+		{
+		}
+		peglib.PushEmpty()
+	choiceSuccessful7:
+		;
+		if !peglib.HasPrefix(input, ">") {
+			peglib.FailString(input, ">")
+			peglib.Pop(3)
+			goto nextChoice5
+		}
+		input = input[1:]
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(3)
+			goto nextChoice5
+		}
+		peglib.MergeLabels(3)
+		peglib.MakeObject("ObjectCreator")
+	}
+	goto choiceSuccessful5
+nextChoice5:
+	;
+	input = beforeChoice5
+	switch input[0] {
+	case '!', '"', '$', '%', '&', '\'', '(', '.', ':', '@', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		input = sequence(input)
+		if input == nil {
+			return peglib.Leave(nil)
+		}
+	}
+choiceSuccessful5:
+	;
+	return peglib.Leave(input)
+}
+func data(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice8 := input
+	switch input[0] {
+	case '\'':
+	case '<':
+		goto alternative19
+	case '@':
+		goto alternative20
+	case '[':
+		goto alternative18
+	case 'f', 't':
+		goto alternative16
+	case '{':
+		goto alternative17
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		input = quotedString(input)
+		if input == nil {
+			goto nextChoice8
+		}
+		peglib.MakeLabel("String")
+		peglib.MakeObject("StringData")
+	}
+	goto choiceSuccessful8
+nextChoice8:
+	;
+	input = beforeChoice8
+	switch input[0] {
+	case '<':
+		goto alternative19
+	case '@':
+		goto alternative20
+	case '[':
+		goto alternative18
+	case 'f', 't':
+	case '{':
+		goto alternative17
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative16:
+	{
+		beforeChoice9 := input
+		switch input[0] {
+		case 'f':
+			goto alternative22
+		case 't':
+		default:
+			peglib.Fail(input, 0)
+			goto nextChoice9
+		}
+		{
+			if !peglib.HasPrefix(input, "true") {
+				peglib.FailString(input, "true")
+				goto nextChoice10
+			}
+			input = input[4:]
+			peglib.PushTrue()
+			peglib.MakeLabel("Value")
+		}
+		goto choiceSuccessful9
+	nextChoice10:
+		;
+		input = beforeChoice9
+		switch input[0] {
+		case 'f':
+		default:
+			peglib.Fail(input, 0)
+			goto nextChoice9
+		}
+	alternative22:
+		{
+			if !peglib.HasPrefix(input, "false") {
+				peglib.FailString(input, "false")
+				goto nextChoice9
+			}
+			input = input[5:]
+			peglib.PushFalse()
+			peglib.MakeLabel("Value")
+		}
+	choiceSuccessful9:
+		;
+		peglib.MakeObject("BooleanData")
+	}
+	goto choiceSuccessful8
+nextChoice9:
+	;
+	input = beforeChoice8
+	switch input[0] {
+	case '<':
+		goto alternative19
+	case '@':
+		goto alternative20
+	case '[':
+		goto alternative18
+	case '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative17:
+	{
+		if !peglib.HasPrefix(input, "{") {
+			peglib.FailString(input, "{")
+			goto nextChoice11
+		}
+		input = input[1:]
+		peglib.PushArray()
+	repetition5:
+		for first4 := true; ; first4 = false {
+			beforeRepetition5 := input
+			if !first4 {
+				if !peglib.HasPrefix(input, ",") {
+					peglib.FailString(input, ",")
+					input = beforeRepetition5
+					break repetition5
+				}
+				input = input[1:]
+			}
+			input = ws(input)
+			if input == nil {
+				input = beforeRepetition5
+				break repetition5
+			}
+			labelStart4 := input
+		repetition6:
+			for first5 := true; ; first5 = false {
+				beforeRepetition6 := input
+				beforeChoice10 := input
+				switch input[0] {
+				case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+					goto alternative24
+				case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+				default:
+					peglib.Fail(input, 0)
+					if first5 {
+						input = beforeRepetition5
+						break repetition5
+					}
+					input = beforeRepetition6
+					break repetition6
+				}
+				{
+					if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+						peglib.Fail(input, 0)
+						goto nextChoice12
+					}
+					input = input[1:]
+				}
+				goto choiceSuccessful10
+			nextChoice12:
+				;
+				input = beforeChoice10
+				switch input[0] {
+				case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				default:
+					peglib.Fail(input, 0)
+					if first5 {
+						input = beforeRepetition5
+						break repetition5
+					}
+					input = beforeRepetition6
+					break repetition6
+				}
+			alternative24:
+				{
+					if input[0] < '0' || input[0] > '9' {
+						peglib.Fail(input, 0)
+						if first5 {
+							input = beforeRepetition5
+							break repetition5
+						}
+						input = beforeRepetition6
+						break repetition6
+					}
+					input = input[1:]
+				}
+			choiceSuccessful10:
+			}
+			peglib.PushInputRange(labelStart4, input)
+			peglib.MakeLabel("Label")
+			if !peglib.HasPrefix(input, ":") {
+				peglib.FailString(input, ":")
+				peglib.Pop(1)
+				input = beforeRepetition5
+				break repetition5
+			}
+			input = input[1:]
+			input = ws(input)
+			if input == nil {
+				peglib.Pop(1)
+				input = beforeRepetition5
+				break repetition5
+			}
+			input = data(input)
+			if input == nil {
+				peglib.Pop(1)
+				input = beforeRepetition5
+				break repetition5
+			}
+			peglib.MakeLabel("data")
+			peglib.MergeLabels(2)
+			peglib.MakeObject("HashDataEntry")
+			peglib.AppendToArray()
+		}
+		peglib.MakeLabel("Entries")
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice11
+		}
+		if !peglib.HasPrefix(input, "}") {
+			peglib.FailString(input, "}")
+			peglib.Pop(1)
+			goto nextChoice11
+		}
+		input = input[1:]
+		peglib.MakeObject("HashData")
+	}
+	goto choiceSuccessful8
+nextChoice11:
+	;
+	input = beforeChoice8
+	switch input[0] {
+	case '<':
+		goto alternative19
+	case '@':
+		goto alternative20
+	case '[':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative18:
+	{
+		if !peglib.HasPrefix(input, "[") {
+			peglib.FailString(input, "[")
+			goto nextChoice13
+		}
+		input = input[1:]
+		peglib.PushArray()
+	repetition7:
+		for first6 := true; ; first6 = false {
+			beforeRepetition7 := input
+			if !first6 {
+				if !peglib.HasPrefix(input, ",") {
+					peglib.FailString(input, ",")
+					input = beforeRepetition7
+					break repetition7
+				}
+				input = input[1:]
+			}
+			input = ws(input)
+			if input == nil {
+				input = beforeRepetition7
+				break repetition7
+			}
+			input = data(input)
+			if input == nil {
+				input = beforeRepetition7
+				break repetition7
+			}
+			peglib.MakeLabel("data")
+			peglib.MakeObject("ArrayDataEntry")
+			peglib.AppendToArray()
+		}
+		peglib.MakeLabel("Entries")
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice13
+		}
+		if !peglib.HasPrefix(input, "]") {
+			peglib.FailString(input, "]")
+			peglib.Pop(1)
+			goto nextChoice13
+		}
+		input = input[1:]
+		peglib.MakeObject("ArrayData")
+	}
+	goto choiceSuccessful8
+nextChoice13:
+	;
+	input = beforeChoice8
+	switch input[0] {
+	case '<':
+	case '@':
+		goto alternative20
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative19:
+	{
+		if !peglib.HasPrefix(input, "<") {
+			peglib.FailString(input, "<")
+			goto nextChoice14
+		}
+		input = input[1:]
+		labelStart5 := input
+	repetition8:
+		for first7 := true; ; first7 = false {
+			beforeRepetition8 := input
+			beforeChoice11 := input
+			switch input[0] {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				goto alternative26
+			case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+			default:
+				peglib.Fail(input, 0)
+				if first7 {
+					goto nextChoice14
+				}
+				input = beforeRepetition8
+				break repetition8
+			}
+			{
+				if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+					peglib.Fail(input, 0)
+					goto nextChoice15
+				}
+				input = input[1:]
+			}
+			goto choiceSuccessful11
+		nextChoice15:
+			;
+			input = beforeChoice11
+			switch input[0] {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			default:
+				peglib.Fail(input, 0)
+				if first7 {
+					goto nextChoice14
+				}
+				input = beforeRepetition8
+				break repetition8
+			}
+		alternative26:
+			{
+				if input[0] < '0' || input[0] > '9' {
+					peglib.Fail(input, 0)
+					if first7 {
+						goto nextChoice14
+					}
+					input = beforeRepetition8
+					break repetition8
+				}
+				input = input[1:]
+			}
+		choiceSuccessful11:
+		}
+		peglib.PushInputRange(labelStart5, input)
+		peglib.MakeLabel("ClassName")
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice14
+		}
+		input = data(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice14
+		}
+		peglib.MakeLabel("data")
+		if !peglib.HasPrefix(input, ">") {
+			peglib.FailString(input, ">")
+			peglib.Pop(2)
+			goto nextChoice14
+		}
+		input = input[1:]
+		peglib.MergeLabels(2)
+		peglib.MakeObject("ObjectData")
+	}
+	goto choiceSuccessful8
+nextChoice14:
+	;
+	input = beforeChoice8
+	switch input[0] {
+	case '@':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative20:
+	{
+		if !peglib.HasPrefix(input, "@") {
+			peglib.FailString(input, "@")
+			return peglib.Leave(nil)
+		}
+		input = input[1:]
+		labelStart6 := input
+	repetition9:
+		for first8 := true; ; first8 = false {
+			beforeRepetition9 := input
+			beforeChoice12 := input
+			switch input[0] {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				goto alternative28
+			case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+			default:
+				peglib.Fail(input, 0)
+				if first8 {
+					return peglib.Leave(nil)
+				}
+				input = beforeRepetition9
+				break repetition9
+			}
+			{
+				if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+					peglib.Fail(input, 0)
+					goto nextChoice16
+				}
+				input = input[1:]
+			}
+			goto choiceSuccessful12
+		nextChoice16:
+			;
+			input = beforeChoice12
+			switch input[0] {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			default:
+				peglib.Fail(input, 0)
+				if first8 {
+					return peglib.Leave(nil)
+				}
+				input = beforeRepetition9
+				break repetition9
+			}
+		alternative28:
+			{
+				if input[0] < '0' || input[0] > '9' {
+					peglib.Fail(input, 0)
+					if first8 {
+						return peglib.Leave(nil)
+					}
+					input = beforeRepetition9
+					break repetition9
+				}
+				input = input[1:]
+			}
+		choiceSuccessful12:
+		}
+		peglib.PushInputRange(labelStart6, input)
+		peglib.MakeLabel("Name")
+		peglib.MakeObject("LabelData")
+	}
+choiceSuccessful8:
+	;
+	return peglib.Leave(input)
+}
+func code(input []byte) []byte {
+	peglib.Enter(input)
+	labelStart7 := input
+	peglib.PushArray()
+repetition10:
+	for {
+		beforeRepetition10 := input
+		beforeChoice13 := input
+		switch input[0] {
+		case 0:
+			peglib.Fail(input, 0)
+			input = beforeRepetition10
+			break repetition10
+		}
+		{
+			beforeLookahead1 := input
+			if input[0] != '{' && input[0] != '}' {
+				peglib.Fail(input, 0)
+				goto lookaheadSuccessful1
+			}
+			input = input[1:]
+			goto nextChoice17
+		lookaheadSuccessful1:
+			input = beforeLookahead1
+			if input[0] == 0 {
+				peglib.Fail(input, 0)
+				goto nextChoice17
+			}
+			input = input[1:]
+		}
+		peglib.PushEmpty()
+		goto choiceSuccessful13
+	nextChoice17:
+		;
+		input = beforeChoice13
+		switch input[0] {
+		case '{':
+		default:
+			peglib.Fail(input, 0)
+			input = beforeRepetition10
+			break repetition10
+		}
+		{
+			if !peglib.HasPrefix(input, "{") {
+				peglib.FailString(input, "{")
+				input = beforeRepetition10
+				break repetition10
+			}
+			input = input[1:]
+			input = code(input)
+			if input == nil {
+				input = beforeRepetition10
+				break repetition10
+			}
+			if !peglib.HasPrefix(input, "}") {
+				peglib.FailString(input, "}")
+				peglib.Pop(1)
+				input = beforeRepetition10
+				break repetition10
+			}
+			input = input[1:]
+		}
+	choiceSuccessful13:
+		;
+		peglib.AppendToArray()
+	}
+	peglib.Pop(1)
+	peglib.PushInputRange(labelStart7, input)
+	return peglib.Leave(input)
+}
+func sequence(input []byte) []byte {
+	peglib.Enter(input)
+	peglib.PushArray()
+repetition11:
+	for first9 := true; ; first9 = false {
+		beforeRepetition11 := input
+		input = labeled(input)
+		if input == nil {
+			if first9 {
+				return peglib.Leave(nil)
+			}
+			input = beforeRepetition11
+			break repetition11
+		}
+		peglib.AppendToArray()
+	}
+	peglib.MakeLabel("Children")
+	peglib.MakeObject("Sequence")
+	return peglib.Leave(input)
+}
+func labeled(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice14 := input
+	switch input[0] {
+	case '!', '"', '$', '&', '\'', '(', '.', ':', '[', '{':
+		goto alternative32
+	case '%', '@', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		beforeChoice15 := input
+		switch input[0] {
+		case '%':
+		default:
+			goto alternative34
+		}
+		{
+			if !peglib.HasPrefix(input, "%") {
+				peglib.FailString(input, "%")
+				goto nextChoice19
+			}
+			input = input[1:]
+			peglib.PushTrue()
+			peglib.MakeLabel("IsLocal")
+		}
+		goto choiceSuccessful15
+	nextChoice19:
+		;
+		input = beforeChoice15
+	alternative34:
+		{
+		}
+		peglib.PushEmpty()
+	choiceSuccessful15:
+		;
+		labelStart8 := input
+		peglib.PushInputRange(labelStart8, input)
+		peglib.MakeLabel("Pos")
+		labelStart9 := input
+		beforeChoice16 := input
+		switch input[0] {
+		case '@':
+		case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+			goto alternative36
+		default:
+			peglib.Fail(input, 0)
+			peglib.Pop(2)
+			goto nextChoice18
+		}
+		{
+			if !peglib.HasPrefix(input, "@") {
+				peglib.FailString(input, "@")
+				goto nextChoice20
+			}
+			input = input[1:]
+		}
+		goto choiceSuccessful16
+	nextChoice20:
+		;
+		input = beforeChoice16
+		switch input[0] {
+		case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+		default:
+			peglib.Fail(input, 0)
+			peglib.Pop(2)
+			goto nextChoice18
+		}
+	alternative36:
+		{
+			if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+				peglib.Fail(input, 0)
+				peglib.Pop(2)
+				goto nextChoice18
+			}
+			input = input[1:]
+		repetition12:
+			for {
+				beforeRepetition12 := input
+				beforeChoice17 := input
+				switch input[0] {
+				case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+					goto alternative38
+				case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+				default:
+					peglib.Fail(input, 0)
+					input = beforeRepetition12
+					break repetition12
+				}
+				{
+					if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+						peglib.Fail(input, 0)
+						goto nextChoice21
+					}
+					input = input[1:]
+				}
+				goto choiceSuccessful17
+			nextChoice21:
+				;
+				input = beforeChoice17
+				switch input[0] {
+				case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				default:
+					peglib.Fail(input, 0)
+					input = beforeRepetition12
+					break repetition12
+				}
+			alternative38:
+				{
+					if input[0] < '0' || input[0] > '9' {
+						peglib.Fail(input, 0)
+						input = beforeRepetition12
+						break repetition12
+					}
+					input = input[1:]
+				}
+			choiceSuccessful17:
+			}
+		}
+	choiceSuccessful16:
+		;
+		peglib.PushInputRange(labelStart9, input)
+		peglib.MakeLabel("Name")
+		if !peglib.HasPrefix(input, ":") {
+			peglib.FailString(input, ":")
+			peglib.Pop(3)
+			goto nextChoice18
+		}
+		input = input[1:]
+		input = lookahead(input)
+		if input == nil {
+			peglib.Pop(3)
+			goto nextChoice18
+		}
+		peglib.MakeLabel("Child")
+		peglib.MergeLabels(4)
+		peglib.MakeObject("Label")
+	}
+	goto choiceSuccessful14
+nextChoice18:
+	;
+	input = beforeChoice14
+	switch input[0] {
+	case '!', '"', '$', '%', '&', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative32:
+	{
+		input = lookahead(input)
+		if input == nil {
+			return peglib.Leave(nil)
+		}
+	}
+choiceSuccessful14:
+	;
+	return peglib.Leave(input)
+}
+func lookahead(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice18 := input
+	switch input[0] {
+	case '!':
+		goto alternative40
+	case '"', '$', '%', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+		goto alternative43
+	case '&':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "&{") {
+			peglib.FailString(input, "&{")
+			goto nextChoice22
+		}
+		input = input[2:]
+		labelStart10 := input
+		peglib.PushInputRange(labelStart10, input)
+		peglib.MakeLabel("Pos")
+		input = code(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice22
+		}
+		peglib.MakeLabel("Code")
+		if !peglib.HasPrefix(input, "}") {
+			peglib.FailString(input, "}")
+			peglib.Pop(2)
+			goto nextChoice22
+		}
+		input = input[1:]
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(2)
+			goto nextChoice22
+		}
+		peglib.MergeLabels(2)
+		peglib.MakeObject("PositivePredicate")
+	}
+	goto choiceSuccessful18
+nextChoice22:
+	;
+	input = beforeChoice18
+	switch input[0] {
+	case '!':
+	case '"', '$', '%', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+		goto alternative43
+	case '&':
+		goto alternative41
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative40:
+	{
+		if !peglib.HasPrefix(input, "!{") {
+			peglib.FailString(input, "!{")
+			goto nextChoice23
+		}
+		input = input[2:]
+		labelStart11 := input
+		peglib.PushInputRange(labelStart11, input)
+		peglib.MakeLabel("Pos")
+		input = code(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice23
+		}
+		peglib.MakeLabel("Code")
+		if !peglib.HasPrefix(input, "}") {
+			peglib.FailString(input, "}")
+			peglib.Pop(2)
+			goto nextChoice23
+		}
+		input = input[1:]
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(2)
+			goto nextChoice23
+		}
+		peglib.MergeLabels(2)
+		peglib.MakeObject("NegativePredicate")
+	}
+	goto choiceSuccessful18
+nextChoice23:
+	;
+	input = beforeChoice18
+	switch input[0] {
+	case '!':
+		goto alternative42
+	case '"', '$', '%', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+		goto alternative43
+	case '&':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative41:
+	{
+		if !peglib.HasPrefix(input, "&") {
+			peglib.FailString(input, "&")
+			goto nextChoice24
+		}
+		input = input[1:]
+		input = repetition(input)
+		if input == nil {
+			goto nextChoice24
+		}
+		peglib.MakeLabel("Child")
+		peglib.MakeObject("PositiveLookahead")
+	}
+	goto choiceSuccessful18
+nextChoice24:
+	;
+	input = beforeChoice18
+	switch input[0] {
+	case '!':
+	case '"', '$', '%', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+		goto alternative43
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative42:
+	{
+		if !peglib.HasPrefix(input, "!") {
+			peglib.FailString(input, "!")
+			goto nextChoice25
+		}
+		input = input[1:]
+		input = repetition(input)
+		if input == nil {
+			goto nextChoice25
+		}
+		peglib.MakeLabel("Child")
+		peglib.MakeObject("NegativeLookahead")
+	}
+	goto choiceSuccessful18
+nextChoice25:
+	;
+	input = beforeChoice18
+	switch input[0] {
+	case '"', '$', '%', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative43:
+	{
+		input = repetition(input)
+		if input == nil {
+			return peglib.Leave(nil)
+		}
+	}
+choiceSuccessful18:
+	;
+	return peglib.Leave(input)
+}
+func repetition(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice19 := input
+	switch input[0] {
+	case '"', '$', '%', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		input = primary(input)
+		if input == nil {
+			goto nextChoice26
+		}
+		peglib.MakeLabel("Child")
+		if !peglib.HasPrefix(input, "?") {
+			peglib.FailString(input, "?")
+			peglib.Pop(1)
+			goto nextChoice26
+		}
+		input = input[1:]
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice26
+		}
+		peglib.SetAsSource()
+		peglib.ReadFromSource("Child")
+		peglib.PushEmpty()
+		peglib.MakeObject("EmptyParsingExpression")
+		peglib.MakeArray(2)
+		peglib.MakeLabel("Children")
+		peglib.ReplaceSource()
+		peglib.MakeObject("Choice")
+	}
+	goto choiceSuccessful19
+nextChoice26:
+	;
+	input = beforeChoice19
+	switch input[0] {
+	case '"', '$', '%', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		input = primary(input)
+		if input == nil {
+			goto nextChoice27
+		}
+		peglib.MakeLabel("Child")
+		if !peglib.HasPrefix(input, "*->") {
+			peglib.FailString(input, "*->")
+			peglib.Pop(1)
+			goto nextChoice27
+		}
+		input = input[3:]
+		input = primary(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice27
+		}
+		peglib.MakeLabel("UntilExpression")
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(2)
+			goto nextChoice27
+		}
+		peglib.MergeLabels(2)
+		peglib.MakeObject("Until")
+	}
+	goto choiceSuccessful19
+nextChoice27:
+	;
+	input = beforeChoice19
+	switch input[0] {
+	case '"', '$', '%', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		input = primary(input)
+		if input == nil {
+			goto nextChoice28
+		}
+		peglib.MakeLabel("Child")
+		beforeChoice20 := input
+		switch input[0] {
+		case '*':
+		case '+':
+			goto alternative49
+		default:
+			peglib.Fail(input, 0)
+			peglib.Pop(1)
+			goto nextChoice28
+		}
+		{
+			if !peglib.HasPrefix(input, "*") {
+				peglib.FailString(input, "*")
+				goto nextChoice29
+			}
+			input = input[1:]
+			peglib.PushFalse()
+			peglib.MakeLabel("AtLeastOnce")
+		}
+		goto choiceSuccessful20
+	nextChoice29:
+		;
+		input = beforeChoice20
+		switch input[0] {
+		case '+':
+		default:
+			peglib.Fail(input, 0)
+			peglib.Pop(1)
+			goto nextChoice28
+		}
+	alternative49:
+		{
+			if !peglib.HasPrefix(input, "+") {
+				peglib.FailString(input, "+")
+				peglib.Pop(1)
+				goto nextChoice28
+			}
+			input = input[1:]
+			peglib.PushTrue()
+			peglib.MakeLabel("AtLeastOnce")
+		}
+	choiceSuccessful20:
+		;
+		beforeChoice21 := input
+		switch input[0] {
+		case '[':
+		default:
+			goto alternative51
+		}
+		{
+			if !peglib.HasPrefix(input, "[") {
+				peglib.FailString(input, "[")
+				goto nextChoice30
+			}
+			input = input[1:]
+			input = ws(input)
+			if input == nil {
+				goto nextChoice30
+			}
+			input = expression(input)
+			if input == nil {
+				goto nextChoice30
+			}
+			peglib.MakeLabel("GlueExpression")
+			if !peglib.HasPrefix(input, "]") {
+				peglib.FailString(input, "]")
+				peglib.Pop(1)
+				goto nextChoice30
+			}
+			input = input[1:]
+		}
+		goto choiceSuccessful21
+	nextChoice30:
+		;
+		input = beforeChoice21
+	alternative51:
+		{
+		}
+		peglib.PushEmpty()
+	choiceSuccessful21:
+		;
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(3)
+			goto nextChoice28
+		}
+		peglib.MergeLabels(3)
+		peglib.MakeObject("Repetition")
+	}
+	goto choiceSuccessful19
+nextChoice28:
+	;
+	input = beforeChoice19
+	switch input[0] {
+	case '"', '$', '%', '\'', '(', '.', ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '[', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		input = primary(input)
+		if input == nil {
+			return peglib.Leave(nil)
+		}
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(1)
+			return peglib.Leave(nil)
+		}
+	}
+choiceSuccessful19:
+	;
+	return peglib.Leave(input)
+}
+func primary(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice22 := input
+	switch input[0] {
+	case '"', '\'', '.', '[':
+	case '$':
+		goto alternative55
+	case '%':
+		goto alternative57
+	case '(':
+		goto alternative54
+	case ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+		goto alternative53
+	case '{':
+		goto alternative58
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		input = terminal(input)
+		if input == nil {
+			goto nextChoice31
+		}
+	}
+	goto choiceSuccessful22
+nextChoice31:
+	;
+	input = beforeChoice22
+	switch input[0] {
+	case '$':
+		goto alternative55
+	case '%':
+		goto alternative57
+	case '(':
+		goto alternative54
+	case ':', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+	case '{':
+		goto alternative58
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative53:
+	{
+		input = ruleCall(input)
+		if input == nil {
+			goto nextChoice32
+		}
+	}
+	goto choiceSuccessful22
+nextChoice32:
+	;
+	input = beforeChoice22
+	switch input[0] {
+	case '$':
+		goto alternative55
+	case '%':
+		goto alternative57
+	case '(':
+	case '{':
+		goto alternative58
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative54:
+	{
+		input = parenthesizedExpression(input)
+		if input == nil {
+			goto nextChoice33
+		}
+	}
+	goto choiceSuccessful22
+nextChoice33:
+	;
+	input = beforeChoice22
+	switch input[0] {
+	case '$':
+	case '%':
+		goto alternative57
+	case '{':
+		goto alternative58
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative55:
+	{
+		input = operatorPrecedence(input)
+		if input == nil {
+			goto nextChoice34
+		}
+	}
+	goto choiceSuccessful22
+nextChoice34:
+	;
+	input = beforeChoice22
+	switch input[0] {
+	case '$':
+	case '%':
+		goto alternative57
+	case '{':
+		goto alternative58
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		input = function(input)
+		if input == nil {
+			goto nextChoice35
+		}
+	}
+	goto choiceSuccessful22
+nextChoice35:
+	;
+	input = beforeChoice22
+	switch input[0] {
+	case '%':
+	case '{':
+		goto alternative58
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative57:
+	{
+		input = localValue(input)
+		if input == nil {
+			goto nextChoice36
+		}
+	}
+	goto choiceSuccessful22
+nextChoice36:
+	;
+	input = beforeChoice22
+	switch input[0] {
+	case '{':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative58:
+	{
+		input = action(input)
+		if input == nil {
+			return peglib.Leave(nil)
+		}
+	}
+choiceSuccessful22:
+	;
+	return peglib.Leave(input)
+}
+func terminal(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice23 := input
+	switch input[0] {
+	case '"':
+		goto alternative60
+	case '\'':
+	case '.':
+		goto alternative62
+	case '[':
+		goto alternative61
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		labelStart12 := input
+		peglib.PushInputRange(labelStart12, input)
+		peglib.MakeLabel("Pos")
+		if !peglib.HasPrefix(input, "'") {
+			peglib.FailString(input, "'")
+			peglib.Pop(1)
+			goto nextChoice37
+		}
+		input = input[1:]
+		labelStart13 := input
+	repetition13:
+		for {
+			beforeRepetition13 := input
+			beforeChoice24 := input
+			switch input[0] {
+			case 0:
+				peglib.Fail(input, 0)
+				input = beforeRepetition13
+				break repetition13
+			case '\\':
+			default:
+				goto alternative64
+			}
+			{
+				if !peglib.HasPrefix(input, "\\") {
+					peglib.FailString(input, "\\")
+					goto nextChoice38
+				}
+				input = input[1:]
+				if input[0] == 0 {
+					peglib.Fail(input, 0)
+					goto nextChoice38
+				}
+				input = input[1:]
+			}
+			goto choiceSuccessful24
+		nextChoice38:
+			;
+			input = beforeChoice24
+			switch input[0] {
+			case 0:
+				peglib.Fail(input, 0)
+				input = beforeRepetition13
+				break repetition13
+			}
+		alternative64:
+			{
+				beforeLookahead2 := input
+				if !peglib.HasPrefix(input, "'") {
+					peglib.FailString(input, "'")
+					goto lookaheadSuccessful2
+				}
+				input = input[1:]
+				input = beforeRepetition13
+				break repetition13
+			lookaheadSuccessful2:
+				input = beforeLookahead2
+				if input[0] == 0 {
+					peglib.Fail(input, 0)
+					input = beforeRepetition13
+					break repetition13
+				}
+				input = input[1:]
+			}
+		choiceSuccessful24:
+		}
+		peglib.PushInputRange(labelStart13, input)
+		peglib.MakeLabel("Chars")
+		if !peglib.HasPrefix(input, "'") {
+			peglib.FailString(input, "'")
+			peglib.Pop(2)
+			goto nextChoice37
+		}
+		input = input[1:]
+		peglib.PushFalse()
+		peglib.MakeLabel("Fold")
+		peglib.MergeLabels(3)
+		peglib.MakeObject("StringTerminal")
+	}
+	goto choiceSuccessful23
+nextChoice37:
+	;
+	input = beforeChoice23
+	switch input[0] {
+	case '"':
+	case '.':
+		goto alternative62
+	case '[':
+		goto alternative61
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative60:
+	{
+		labelStart14 := input
+		peglib.PushInputRange(labelStart14, input)
+		peglib.MakeLabel("Pos")
+		if !peglib.HasPrefix(input, "\"") {
+			peglib.FailString(input, "\"")
+			peglib.Pop(1)
+			goto nextChoice39
+		}
+		input = input[1:]
+		labelStart15 := input
+	repetition14:
+		for {
+			beforeRepetition14 := input
+			beforeChoice25 := input
+			switch input[0] {
+			case 0:
+				peglib.Fail(input, 0)
+				input = beforeRepetition14
+				break repetition14
+			case '\\':
+			default:
+				goto alternative66
+			}
+			{
+				if !peglib.HasPrefix(input, "\\") {
+					peglib.FailString(input, "\\")
+					goto nextChoice40
+				}
+				input = input[1:]
+				if input[0] == 0 {
+					peglib.Fail(input, 0)
+					goto nextChoice40
+				}
+				input = input[1:]
+			}
+			goto choiceSuccessful25
+		nextChoice40:
+			;
+			input = beforeChoice25
+			switch input[0] {
+			case 0:
+				peglib.Fail(input, 0)
+				input = beforeRepetition14
+				break repetition14
+			}
+		alternative66:
+			{
+				beforeLookahead3 := input
+				if !peglib.HasPrefix(input, "\"") {
+					peglib.FailString(input, "\"")
+					goto lookaheadSuccessful3
+				}
+				input = input[1:]
+				input = beforeRepetition14
+				break repetition14
+			lookaheadSuccessful3:
+				input = beforeLookahead3
+				if input[0] == 0 {
+					peglib.Fail(input, 0)
+					input = beforeRepetition14
+					break repetition14
+				}
+				input = input[1:]
+			}
+		choiceSuccessful25:
+		}
+		peglib.PushInputRange(labelStart15, input)
+		peglib.MakeLabel("Chars")
+		if !peglib.HasPrefix(input, "\"") {
+			peglib.FailString(input, "\"")
+			peglib.Pop(2)
+			goto nextChoice39
+		}
+		input = input[1:]
+		peglib.PushTrue()
+		peglib.MakeLabel("Fold")
+		peglib.MergeLabels(3)
+		peglib.MakeObject("StringTerminal")
+	}
+	goto choiceSuccessful23
+nextChoice39:
+	;
+	input = beforeChoice23
+	switch input[0] {
+	case '.':
+		goto alternative62
+	case '[':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative61:
+	{
+		labelStart16 := input
+		peglib.PushInputRange(labelStart16, input)
+		peglib.MakeLabel("Pos")
+		if !peglib.HasPrefix(input, "[") {
+			peglib.FailString(input, "[")
+			peglib.Pop(1)
+			goto nextChoice41
+		}
+		input = input[1:]
+		beforeChoice26 := input
+		switch input[0] {
+		case '^':
+		default:
+			goto alternative68
+		}
+		{
+			if !peglib.HasPrefix(input, "^") {
+				peglib.FailString(input, "^")
+				goto nextChoice42
+			}
+			input = input[1:]
+			peglib.PushTrue()
+			peglib.MakeLabel("Inverted")
+		}
+		goto choiceSuccessful26
+	nextChoice42:
+		;
+		input = beforeChoice26
+	alternative68:
+		{
+		}
+		peglib.PushEmpty()
+	choiceSuccessful26:
+		;
+		peglib.PushArray()
+	repetition15:
+		for {
+			beforeRepetition15 := input
+			input = characterClassSelector(input)
+			if input == nil {
+				input = beforeRepetition15
+				break repetition15
+			}
+			peglib.AppendToArray()
+		}
+		peglib.MakeLabel("Selections")
+		if !peglib.HasPrefix(input, "]") {
+			peglib.FailString(input, "]")
+			peglib.Pop(3)
+			goto nextChoice41
+		}
+		input = input[1:]
+		peglib.MergeLabels(3)
+		peglib.MakeObject("CharacterClassTerminal")
+	}
+	goto choiceSuccessful23
+nextChoice41:
+	;
+	input = beforeChoice23
+	switch input[0] {
+	case '.':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative62:
+	{
+		labelStart17 := input
+		peglib.PushInputRange(labelStart17, input)
+		peglib.MakeLabel("Pos")
+		if !peglib.HasPrefix(input, ".") {
+			peglib.FailString(input, ".")
+			peglib.Pop(1)
+			return peglib.Leave(nil)
+		}
+		input = input[1:]
+		peglib.SetAsSource()
+		peglib.PushString("\\0")
+		peglib.MakeLabel("Char")
+		peglib.MakeObject("CharacterClassSingleCharacter")
+		peglib.MakeArray(1)
+		peglib.MakeLabel("Selections")
+		peglib.PushTrue()
+		peglib.MakeLabel("Inverted")
+		peglib.ReadFromSource("Pos")
+		peglib.MakeLabel("Pos")
+		peglib.MergeLabels(3)
+		peglib.ReplaceSource()
+		peglib.MakeObject("CharacterClassTerminal")
+	}
+choiceSuccessful23:
+	;
+	return peglib.Leave(input)
+}
+func characterClassSelector(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice27 := input
+	switch input[0] {
+	case 0:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		labelStart18 := input
+		input = characterClassSingleCharacter(input)
+		if input == nil {
+			goto nextChoice43
+		}
+		peglib.PushInputRange(labelStart18, input)
+		peglib.MakeLabel("BeginChar")
+		if !peglib.HasPrefix(input, "-") {
+			peglib.FailString(input, "-")
+			peglib.Pop(1)
+			goto nextChoice43
+		}
+		input = input[1:]
+		labelStart19 := input
+		input = characterClassSingleCharacter(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice43
+		}
+		peglib.PushInputRange(labelStart19, input)
+		peglib.MakeLabel("EndChar")
+		peglib.MergeLabels(2)
+		peglib.MakeObject("CharacterClassRange")
+	}
+	goto choiceSuccessful27
+nextChoice43:
+	;
+	input = beforeChoice27
+	switch input[0] {
+	case 0:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		labelStart20 := input
+		input = characterClassSingleCharacter(input)
+		if input == nil {
+			return peglib.Leave(nil)
+		}
+		peglib.PushInputRange(labelStart20, input)
+		peglib.MakeLabel("Char")
+		peglib.MakeObject("CharacterClassSingleCharacter")
+	}
+choiceSuccessful27:
+	;
+	return peglib.Leave(input)
+}
+func characterClassSingleCharacter(input []byte) []byte {
+	peglib.Enter(input)
+	beforeLookahead4 := input
+	if !peglib.HasPrefix(input, "]") {
+		peglib.FailString(input, "]")
+		goto lookaheadSuccessful4
+	}
+	input = input[1:]
+	return peglib.Leave(nil)
+lookaheadSuccessful4:
+	input = beforeLookahead4
+	beforeChoice28 := input
+	switch input[0] {
+	case 0:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	case '\\':
+	default:
+		goto alternative72
+	}
+	{
+		if !peglib.HasPrefix(input, "\\") {
+			peglib.FailString(input, "\\")
+			goto nextChoice44
+		}
+		input = input[1:]
+		if input[0] == 0 {
+			peglib.Fail(input, 0)
+			goto nextChoice44
+		}
+		input = input[1:]
+	}
+	goto choiceSuccessful28
+nextChoice44:
+	;
+	input = beforeChoice28
+	switch input[0] {
+	case 0:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative72:
+	{
+		if input[0] == 0 {
+			peglib.Fail(input, 0)
+			return peglib.Leave(nil)
+		}
+		input = input[1:]
+	}
+choiceSuccessful28:
+	;
+	return peglib.Leave(input)
+}
+func ruleCall(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice29 := input
+	switch input[0] {
+	case ':':
+	case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+		goto alternative74
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, ":") {
+			peglib.FailString(input, ":")
+			goto nextChoice45
+		}
+		input = input[1:]
+		labelStart21 := input
+		peglib.PushInputRange(labelStart21, input)
+		peglib.MakeLabel("Pos")
+		input = ruleName(input)
+		if input == nil {
+			peglib.Pop(1)
+			goto nextChoice45
+		}
+		peglib.MakeLabel("Name")
+		beforeChoice30 := input
+		switch input[0] {
+		case '[':
+		default:
+			goto alternative76
+		}
+		{
+			input = arguments(input)
+			if input == nil {
+				goto nextChoice46
+			}
+			peglib.MakeLabel("arguments")
+		}
+		goto choiceSuccessful30
+	nextChoice46:
+		;
+		input = beforeChoice30
+	alternative76:
+		{
+		}
+		peglib.PushEmpty()
+	choiceSuccessful30:
+		;
+		peglib.MergeLabels(3)
+		peglib.SetAsSource()
+		peglib.ReadFromSource("Name")
+		peglib.MakeLabel("Name")
+		peglib.ReadFromSource("Name")
+		peglib.MakeLabel("Name")
+		peglib.ReadFromSource("arguments")
+		peglib.MakeLabel("Arguments")
+		peglib.ReadFromSource("Pos")
+		peglib.MakeLabel("Pos")
+		peglib.MergeLabels(3)
+		peglib.MakeObject("RuleCall")
+		peglib.MakeLabel("Child")
+		peglib.ReadFromSource("Pos")
+		peglib.MakeLabel("Pos")
+		peglib.MergeLabels(3)
+		peglib.ReplaceSource()
+		peglib.MakeObject("Label")
+	}
+	goto choiceSuccessful29
+nextChoice45:
+	;
+	input = beforeChoice29
+	switch input[0] {
+	case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative74:
+	{
+		labelStart22 := input
+		peglib.PushInputRange(labelStart22, input)
+		peglib.MakeLabel("Pos")
+		input = ruleName(input)
+		if input == nil {
+			peglib.Pop(1)
+			return peglib.Leave(nil)
+		}
+		peglib.MakeLabel("Name")
+		beforeChoice31 := input
+		switch input[0] {
+		case '[':
+		default:
+			goto alternative78
+		}
+		{
+			input = arguments(input)
+			if input == nil {
+				goto nextChoice47
+			}
+			peglib.MakeLabel("arguments")
+		}
+		goto choiceSuccessful31
+	nextChoice47:
+		;
+		input = beforeChoice31
+	alternative78:
+		{
+		}
+		peglib.PushEmpty()
+	choiceSuccessful31:
+		;
+		peglib.MergeLabels(3)
+		peglib.MakeObject("RuleCall")
+	}
+choiceSuccessful29:
+	;
+	return peglib.Leave(input)
+}
+func arguments(input []byte) []byte {
+	peglib.Enter(input)
+	if !peglib.HasPrefix(input, "[") {
+		peglib.FailString(input, "[")
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+	peglib.PushArray()
+repetition16:
+	for first10 := true; ; first10 = false {
+		beforeRepetition16 := input
+		if !first10 {
+			if !peglib.HasPrefix(input, ",") {
+				peglib.FailString(input, ",")
+				input = beforeRepetition16
+				break repetition16
+			}
+			input = input[1:]
+			input = ws(input)
+			if input == nil {
+				input = beforeRepetition16
+				break repetition16
+			}
+		}
+		beforeChoice32 := input
+		switch input[0] {
+		case '$':
+			goto alternative80
+		case '%':
+			goto alternative81
+		case '\'':
+		default:
+			peglib.Fail(input, 0)
+			input = beforeRepetition16
+			break repetition16
+		}
+		{
+			input = quotedString(input)
+			if input == nil {
+				goto nextChoice48
+			}
+			peglib.MakeLabel("String")
+			peglib.MakeObject("StringValue")
+		}
+		goto choiceSuccessful32
+	nextChoice48:
+		;
+		input = beforeChoice32
+		switch input[0] {
+		case '$':
+		case '%':
+			goto alternative81
+		default:
+			peglib.Fail(input, 0)
+			input = beforeRepetition16
+			break repetition16
+		}
+	alternative80:
+		{
+			input = function(input)
+			if input == nil {
+				goto nextChoice49
+			}
+		}
+		goto choiceSuccessful32
+	nextChoice49:
+		;
+		input = beforeChoice32
+		switch input[0] {
+		case '%':
+		default:
+			peglib.Fail(input, 0)
+			input = beforeRepetition16
+			break repetition16
+		}
+	alternative81:
+		{
+			input = localValue(input)
+			if input == nil {
+				input = beforeRepetition16
+				break repetition16
+			}
+		}
+	choiceSuccessful32:
+		;
+		peglib.AppendToArray()
+	}
+	if !peglib.HasPrefix(input, "]") {
+		peglib.FailString(input, "]")
+		peglib.Pop(1)
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+	return peglib.Leave(input)
+}
+func parenthesizedExpression(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice33 := input
+	switch input[0] {
+	case '(':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "(") {
+			peglib.FailString(input, "(")
+			goto nextChoice50
+		}
+		input = input[1:]
+		input = ws(input)
+		if input == nil {
+			goto nextChoice50
+		}
+		if !peglib.HasPrefix(input, ")") {
+			peglib.FailString(input, ")")
+			goto nextChoice50
+		}
+		input = input[1:]
+		peglib.PushEmpty()
+		peglib.SetAsSource()
+		peglib.PushEmpty()
+		peglib.ReplaceSource()
+		peglib.MakeObject("EmptyParsingExpression")
+	}
+	goto choiceSuccessful33
+nextChoice50:
+	;
+	input = beforeChoice33
+	switch input[0] {
+	case '(':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "(") {
+			peglib.FailString(input, "(")
+			return peglib.Leave(nil)
+		}
+		input = input[1:]
+		input = ws(input)
+		if input == nil {
+			return peglib.Leave(nil)
+		}
+		input = expression(input)
+		if input == nil {
+			return peglib.Leave(nil)
+		}
+		peglib.MakeLabel("Child")
+		if !peglib.HasPrefix(input, ")") {
+			peglib.FailString(input, ")")
+			peglib.Pop(1)
+			return peglib.Leave(nil)
+		}
+		input = input[1:]
+		peglib.MakeObject("ParenthesizedExpression")
+	}
+choiceSuccessful33:
+	;
+	return peglib.Leave(input)
+}
+func operatorPrecedence(input []byte) []byte {
+	peglib.Enter(input)
+	if !peglib.HasPrefix(input, "$Precedence[") {
+		peglib.FailString(input, "$Precedence[")
+		return peglib.Leave(nil)
+	}
+	input = input[12:]
+	input = ws(input)
+	if input == nil {
+		return peglib.Leave(nil)
+	}
+	input = primary(input)
+	if input == nil {
+		return peglib.Leave(nil)
+	}
+	peglib.MakeLabel("Operand")
+	input = ws(input)
+	if input == nil {
+		peglib.Pop(1)
+		return peglib.Leave(nil)
+	}
+	peglib.PushArray()
+repetition17:
+	for first11 := true; ; first11 = false {
+		beforeRepetition17 := input
+		labelStart23 := input
+		beforeChoice34 := input
+		switch input[0] {
+		case 'l':
+		case 'p':
+			goto alternative86
+		case 'r':
+			goto alternative85
+		default:
+			peglib.Fail(input, 0)
+			if first11 {
+				peglib.Pop(1)
+				return peglib.Leave(nil)
+			}
+			input = beforeRepetition17
+			break repetition17
+		}
+		{
+			if !peglib.HasPrefix(input, "left") {
+				peglib.FailString(input, "left")
+				goto nextChoice51
+			}
+			input = input[4:]
+		}
+		goto choiceSuccessful34
+	nextChoice51:
+		;
+		input = beforeChoice34
+		switch input[0] {
+		case 'p':
+			goto alternative86
+		case 'r':
+		default:
+			peglib.Fail(input, 0)
+			if first11 {
+				peglib.Pop(1)
+				return peglib.Leave(nil)
+			}
+			input = beforeRepetition17
+			break repetition17
+		}
+	alternative85:
+		{
+			if !peglib.HasPrefix(input, "right") {
+				peglib.FailString(input, "right")
+				goto nextChoice52
+			}
+			input = input[5:]
+		}
+		goto choiceSuccessful34
+	nextChoice52:
+		;
+		input = beforeChoice34
+		switch input[0] {
+		case 'p':
+		default:
+			peglib.Fail(input, 0)
+			if first11 {
+				peglib.Pop(1)
+				return peglib.Leave(nil)
+			}
+			input = beforeRepetition17
+			break repetition17
+		}
+	alternative86:
+		{
+			if !peglib.HasPrefix(input, "prefix") {
+				peglib.FailString(input, "prefix")
+				goto nextChoice53
+			}
+			input = input[6:]
+		}
+		goto choiceSuccessful34
+	nextChoice53:
+		;
+		input = beforeChoice34
+		switch input[0] {
+		case 'p':
+		default:
+			peglib.Fail(input, 0)
+			if first11 {
+				peglib.Pop(1)
+				return peglib.Leave(nil)
+			}
+			input = beforeRepetition17
+			break repetition17
+		}
+		{
+			if !peglib.HasPrefix(input, "postfix") {
+				peglib.FailString(input, "postfix")
+				if first11 {
+					peglib.Pop(1)
+					return peglib.Leave(nil)
+				}
+				input = beforeRepetition17
+				break repetition17
+			}
+			input = input[7:]
+		}
+	choiceSuccessful34:
+		;
+		peglib.PushInputRange(labelStart23, input)
+		peglib.MakeLabel("Kind")
+		input = ws(input)
+		if input == nil {
+			peglib.Pop(1)
+			if first11 {
+				peglib.Pop(1)
+				return peglib.Leave(nil)
+			}
+			input = beforeRepetition17
+			break repetition17
+		}
+		peglib.PushArray()
+	repetition18:
+		for first12 := true; ; first12 = false {
+			beforeRepetition18 := input
+			if !first12 {
+				if !peglib.HasPrefix(input, "/") {
+					peglib.FailString(input, "/")
+					if first12 {
+						peglib.Pop(1)
+						if first11 {
+							peglib.Pop(1)
+							return peglib.Leave(nil)
+						}
+						input = beforeRepetition17
+						break repetition17
+					}
+					input = beforeRepetition18
+					break repetition18
+				}
+				input = input[1:]
+				input = ws(input)
+				if input == nil {
+					if first12 {
+						peglib.Pop(1)
+						if first11 {
+							peglib.Pop(1)
+							return peglib.Leave(nil)
+						}
+						input = beforeRepetition17
+						break repetition17
+					}
+					input = beforeRepetition18
+					break repetition18
+				}
+			}
+			input = primary(input)
+			if input == nil {
+				if first12 {
+					peglib.Pop(1)
+					if first11 {
+						peglib.Pop(1)
+						return peglib.Leave(nil)
+					}
+					input = beforeRepetition17
+					break repetition17
+				}
+				input = beforeRepetition18
+				break repetition18
+			}
+			input = ws(input)
+			if input == nil {
+				peglib.Pop(1)
+				if first12 {
+					peglib.Pop(1)
+					if first11 {
+						peglib.Pop(1)
+						return peglib.Leave(nil)
+					}
+					input = beforeRepetition17
+					break repetition17
+				}
+				input = beforeRepetition18
+				break repetition18
+			}
+			peglib.AppendToArray()
+		}
+		peglib.MakeLabel("Operators")
+		peglib.MergeLabels(2)
+		peglib.MakeObject("PrecedenceLevel")
+		peglib.AppendToArray()
+	}
+	peglib.MakeLabel("Levels")
+	if !peglib.HasPrefix(input, "]") {
+		peglib.FailString(input, "]")
+		peglib.Pop(2)
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+	peglib.MergeLabels(2)
+	peglib.MakeObject("OperatorPrecedence")
+	return peglib.Leave(input)
+}
+func function(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice35 := input
+	switch input[0] {
+	case '$':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "$True") {
+			peglib.FailString(input, "$True")
+			goto nextChoice54
+		}
+		input = input[5:]
+		peglib.PushEmpty()
+		peglib.MakeObject("TrueFunction")
+	}
+	goto choiceSuccessful35
+nextChoice54:
+	;
+	input = beforeChoice35
+	switch input[0] {
+	case '$':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "$False") {
+			peglib.FailString(input, "$False")
+			goto nextChoice55
+		}
+		input = input[6:]
+		peglib.PushEmpty()
+		peglib.MakeObject("FalseFunction")
+	}
+	goto choiceSuccessful35
+nextChoice55:
+	;
+	input = beforeChoice35
+	switch input[0] {
+	case '$':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "$Match[") {
+			peglib.FailString(input, "$Match[")
+			goto nextChoice56
+		}
+		input = input[7:]
+		input = localValue(input)
+		if input == nil {
+			goto nextChoice56
+		}
+		peglib.MakeLabel("Value")
+		if !peglib.HasPrefix(input, "]") {
+			peglib.FailString(input, "]")
+			peglib.Pop(1)
+			goto nextChoice56
+		}
+		input = input[1:]
+		peglib.MakeObject("MatchFunction")
+	}
+	goto choiceSuccessful35
+nextChoice56:
+	;
+	input = beforeChoice35
+	switch input[0] {
+	case '$':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "$Error[") {
+			peglib.FailString(input, "$Error[")
+			goto nextChoice57
+		}
+		input = input[7:]
+		input = quotedString(input)
+		if input == nil {
+			goto nextChoice57
+		}
+		peglib.MakeLabel("Msg")
+		if !peglib.HasPrefix(input, "]") {
+			peglib.FailString(input, "]")
+			peglib.Pop(1)
+			goto nextChoice57
+		}
+		input = input[1:]
+		peglib.MakeObject("ErrorFunction")
+	}
+	goto choiceSuccessful35
+nextChoice57:
+	;
+	input = beforeChoice35
+	switch input[0] {
+	case '$':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "$Indent") {
+			peglib.FailString(input, "$Indent")
+			goto nextChoice58
+		}
+		input = input[7:]
+		peglib.PushEmpty()
+		peglib.MakeObject("IndentFunction")
+	}
+	goto choiceSuccessful35
+nextChoice58:
+	;
+	input = beforeChoice35
+	switch input[0] {
+	case '$':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "$Samedent") {
+			peglib.FailString(input, "$Samedent")
+			goto nextChoice59
+		}
+		input = input[9:]
+		peglib.PushEmpty()
+		peglib.MakeObject("SamedentFunction")
+	}
+	goto choiceSuccessful35
+nextChoice59:
+	;
+	input = beforeChoice35
+	switch input[0] {
+	case '$':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "$Dedent") {
+			peglib.FailString(input, "$Dedent")
+			goto nextChoice60
+		}
+		input = input[7:]
+		peglib.PushEmpty()
+		peglib.MakeObject("DedentFunction")
+	}
+	goto choiceSuccessful35
+nextChoice60:
+	;
+	input = beforeChoice35
+	switch input[0] {
+	case '$':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		labelStart24 := input
+		peglib.PushInputRange(labelStart24, input)
+		peglib.MakeLabel("Pos")
+		if !peglib.HasPrefix(input, "$Commit") {
+			peglib.FailString(input, "$Commit")
+			peglib.Pop(1)
+			return peglib.Leave(nil)
+		}
+		input = input[7:]
+		peglib.MakeObject("CommitFunction")
+	}
+choiceSuccessful35:
+	;
+	return peglib.Leave(input)
+}
+func action(input []byte) []byte {
+	peglib.Enter(input)
+	if !peglib.HasPrefix(input, "{") {
+		peglib.FailString(input, "{")
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+	labelStart25 := input
+	peglib.PushInputRange(labelStart25, input)
+	peglib.MakeLabel("Pos")
+	input = code(input)
+	if input == nil {
+		peglib.Pop(1)
+		return peglib.Leave(nil)
+	}
+	peglib.MakeLabel("Code")
+	if !peglib.HasPrefix(input, "}") {
+		peglib.FailString(input, "}")
+		peglib.Pop(2)
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+	peglib.MergeLabels(2)
+	peglib.MakeObject("Action")
+	return peglib.Leave(input)
+}
+func localValue(input []byte) []byte {
+	peglib.Enter(input)
+	if !peglib.HasPrefix(input, "%") {
+		peglib.FailString(input, "%")
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+	labelStart26 := input
+	if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+repetition19:
+	for {
+		beforeRepetition19 := input
+		beforeChoice36 := input
+		switch input[0] {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			goto alternative97
+		case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+		default:
+			peglib.Fail(input, 0)
+			input = beforeRepetition19
+			break repetition19
+		}
+		{
+			if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+				peglib.Fail(input, 0)
+				goto nextChoice61
+			}
+			input = input[1:]
+		}
+		goto choiceSuccessful36
+	nextChoice61:
+		;
+		input = beforeChoice36
+		switch input[0] {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		default:
+			peglib.Fail(input, 0)
+			input = beforeRepetition19
+			break repetition19
+		}
+	alternative97:
+		{
+			if input[0] < '0' || input[0] > '9' {
+				peglib.Fail(input, 0)
+				input = beforeRepetition19
+				break repetition19
+			}
+			input = input[1:]
+		}
+	choiceSuccessful36:
+	}
+	peglib.PushInputRange(labelStart26, input)
+	peglib.MakeLabel("Name")
+	peglib.MakeObject("LocalValue")
+	return peglib.Leave(input)
+}
+func ruleName(input []byte) []byte {
+	peglib.Enter(input)
+	beforeLookahead5 := input
+	input = keyword(input)
+	if input == nil {
+		goto lookaheadSuccessful5
+	}
+	return peglib.Leave(nil)
+lookaheadSuccessful5:
+	input = beforeLookahead5
+	labelStart27 := input
+	if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+repetition20:
+	for {
+		beforeRepetition20 := input
+		beforeChoice37 := input
+		switch input[0] {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			goto alternative99
+		case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+		default:
+			peglib.Fail(input, 0)
+			input = beforeRepetition20
+			break repetition20
+		}
+		{
+			if (input[0] < 'A' || input[0] > 'Z') && input[0] != '_' && (input[0] < 'a' || input[0] > 'z') {
+				peglib.Fail(input, 0)
+				goto nextChoice62
+			}
+			input = input[1:]
+		}
+		goto choiceSuccessful37
+	nextChoice62:
+		;
+		input = beforeChoice37
+		switch input[0] {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		default:
+			peglib.Fail(input, 0)
+			input = beforeRepetition20
+			break repetition20
+		}
+	alternative99:
+		{
+			if input[0] < '0' || input[0] > '9' {
+				peglib.Fail(input, 0)
+				input = beforeRepetition20
+				break repetition20
+			}
+			input = input[1:]
+		}
+	choiceSuccessful37:
+	}
+	peglib.PushInputRange(labelStart27, input)
+	return peglib.Leave(input)
+}
+func quotedString(input []byte) []byte {
+	peglib.Enter(input)
+	if !peglib.HasPrefix(input, "'") {
+		peglib.FailString(input, "'")
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+	labelStart28 := input
+repetition21:
+	for {
+		beforeRepetition21 := input
+		beforeLookahead6 := input
+		if !peglib.HasPrefix(input, "'") {
+			peglib.FailString(input, "'")
+			goto lookaheadSuccessful6
+		}
+		input = input[1:]
+		input = beforeRepetition21
+		break repetition21
+	lookaheadSuccessful6:
+		input = beforeLookahead6
+		beforeChoice38 := input
+		switch input[0] {
+		case 0:
+			peglib.Fail(input, 0)
+			input = beforeRepetition21
+			break repetition21
+		case '\\':
+		default:
+			goto alternative101
+		}
+		{
+			if !peglib.HasPrefix(input, "\\") {
+				peglib.FailString(input, "\\")
+				goto nextChoice63
+			}
+			input = input[1:]
+			if input[0] == 0 {
+				peglib.Fail(input, 0)
+				goto nextChoice63
+			}
+			input = input[1:]
+		}
+		goto choiceSuccessful38
+	nextChoice63:
+		;
+		input = beforeChoice38
+		switch input[0] {
+		case 0:
+			peglib.Fail(input, 0)
+			input = beforeRepetition21
+			break repetition21
+		}
+	alternative101:
+		{
+			if input[0] == 0 {
+				peglib.Fail(input, 0)
+				input = beforeRepetition21
+				break repetition21
+			}
+			input = input[1:]
+		}
+	choiceSuccessful38:
+	}
+	peglib.PushInputRange(labelStart28, input)
+	if !peglib.HasPrefix(input, "'") {
+		peglib.FailString(input, "'")
+		peglib.Pop(1)
+		return peglib.Leave(nil)
+	}
+	input = input[1:]
+	return peglib.Leave(input)
+}
+func keyword(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice39 := input
+	switch input[0] {
+	case 'e':
+		goto alternative103
+	case 'r':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if !peglib.HasPrefix(input, "rule") {
+			peglib.FailString(input, "rule")
+			goto nextChoice64
+		}
+		input = input[4:]
+	}
+	goto choiceSuccessful39
+nextChoice64:
+	;
+	input = beforeChoice39
+	switch input[0] {
+	case 'e':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative103:
+	{
+		if !peglib.HasPrefix(input, "end") {
+			peglib.FailString(input, "end")
+			return peglib.Leave(nil)
+		}
+		input = input[3:]
+	}
+choiceSuccessful39:
+	;
+	beforeLookahead7 := input
+	beforeChoice40 := input
+	switch input[0] {
+	case 9, 10, 13, ' ':
+	case '#':
+		goto alternative105
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if (input[0] < 9 || input[0] > 10) && input[0] != 13 && input[0] != ' ' {
+			peglib.Fail(input, 0)
+			goto nextChoice65
+		}
+		input = input[1:]
+	}
+	goto choiceSuccessful40
+nextChoice65:
+	;
+	input = beforeChoice40
+	switch input[0] {
+	case '#':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative105:
+	{
+		if !peglib.HasPrefix(input, "#") {
+			peglib.FailString(input, "#")
+			return peglib.Leave(nil)
+		}
+		input = input[1:]
+		{
+			i1 := 0
+			for i1 < len(input) && input[i1] != 10 {
+				i1++
+			}
+			peglib.Fail(input, i1)
+			input = input[i1:]
+		}
+	}
+choiceSuccessful40:
+	;
+	input = beforeLookahead7
+	return peglib.Leave(input)
+}
+func ws(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice41 := input
+	switch input[0] {
+	case 9, 10, 13, ' ', '#':
+	default:
+		goto alternative107
+	}
+	{
+	repetition22:
+		for first13 := true; ; first13 = false {
+			beforeRepetition22 := input
+			input = singlews(input)
+			if input == nil {
+				if first13 {
+					goto nextChoice66
+				}
+				input = beforeRepetition22
+				break repetition22
+			}
+		}
+	}
+	goto choiceSuccessful41
+nextChoice66:
+	;
+	input = beforeChoice41
+alternative107:
+	{
+		beforeLookahead8 := input
+		if !peglib.HasPrefix(input, "]") {
+			peglib.FailString(input, "]")
+			goto nextChoice67
+		}
+		input = input[1:]
+		input = beforeLookahead8
+	}
+	goto choiceSuccessful41
+nextChoice67:
+	;
+	input = beforeChoice41
+	{
+		beforeLookahead9 := input
+		if !peglib.HasPrefix(input, "\x00") {
+			peglib.FailString(input, "\x00")
+			return peglib.Leave(nil)
+		}
+		input = input[1:]
+		input = beforeLookahead9
+	}
+choiceSuccessful41:
+	;
+	return peglib.Leave(input)
+}
+func singlews(input []byte) []byte {
+	peglib.Enter(input)
+	beforeChoice42 := input
+	switch input[0] {
+	case 9, 10, 13, ' ':
+	case '#':
+		goto alternative110
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+	{
+		if (input[0] < 9 || input[0] > 10) && input[0] != 13 && input[0] != ' ' {
+			peglib.Fail(input, 0)
+			goto nextChoice68
+		}
+		input = input[1:]
+	}
+	goto choiceSuccessful42
+nextChoice68:
+	;
+	input = beforeChoice42
+	switch input[0] {
+	case '#':
+	default:
+		peglib.Fail(input, 0)
+		return peglib.Leave(nil)
+	}
+alternative110:
+	{
+		if !peglib.HasPrefix(input, "#") {
+			peglib.FailString(input, "#")
+			return peglib.Leave(nil)
+		}
+		input = input[1:]
+		{
+			i2 := 0
+			for i2 < len(input) && input[i2] != 10 {
+				i2++
+			}
+			peglib.Fail(input, i2)
+			input = input[i2:]
+		}
+	}
+choiceSuccessful42:
+	;
+	return peglib.Leave(input)
+}
@@ -0,0 +1,52 @@
+package peggen
+
+import (
+	"go/token"
+	"strings"
+
+	"github.com/neelance/peg/peglib"
+)
+
+// locator assigns grammar positions to parsed expressions. The metagrammar
+// labels the empty input range at the start of each located expression with
+// Pos. Input ranges share the memory of the input, so the distance between
+// two ranges is the difference of their capacities, and a range is located
+// relative to the one at the end of the grammar once the parse is complete.
+type locator struct {
+	filename string
+	src      string
+	pending  []pendingPos
+}
+
+type pendingPos struct {
+	pos   *token.Position
+	start peglib.InputRange
+}
+
+// record remembers to set *pos to the position of start when resolving.
+func (l *locator) record(pos *token.Position, start peglib.InputRange) {
+	l.pending = append(l.pending, pendingPos{pos: pos, start: start})
+}
+
+// resolve sets the recorded positions. end is the input range at the end of
+// the grammar.
+func (l *locator) resolve(end peglib.InputRange) {
+	for _, p := range l.pending {
+		*p.pos = l.rangePosition(p.start, end)
+	}
+	l.pending = nil
+}
+
+func (l *locator) rangePosition(start, end peglib.InputRange) token.Position {
+	return l.position(len(l.src) - (cap(start) - cap(end)))
+}
+
+func (l *locator) position(offset int) token.Position {
+	before := l.src[:offset]
+	return token.Position{
+		Filename: l.filename,
+		Offset:   offset,
+		Line:     strings.Count(before, "\n") + 1,
+		Column:   offset - strings.LastIndex(before, "\n"),
+	}
+}
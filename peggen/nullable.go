@@ -0,0 +1,117 @@
+package peggen
+
+import (
+	"go/token"
+)
+
+// computeNullable sets Rule.Nullable for all rules. Since rules may call each
+// other recursively, all rules start out as not nullable and are updated until
+// nothing changes anymore.
+func (c *Context) computeNullable() {
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range c.ruleList {
+			if !rule.Nullable && c.nullable(rule.Child) {
+				rule.Nullable = true
+				changed = true
+			}
+		}
+	}
+}
+
+// nullable reports whether expr can succeed without consuming any input.
+func (c *Context) nullable(expr ParsingExpression) bool {
+	switch e := expr.(type) {
+	case *Rule:
+		return e.Nullable
+
+	case *RuleCall:
+		rule, ok := c.Rules[e.Name.String()]
+		return ok && rule.Nullable
+
+	case *StringTerminal:
+		return e.Chars.String() == ""
+
+	case *CharacterClassTerminal:
+		return false
+
+	case *Sequence:
+		for _, child := range e.Children {
+			if !c.nullable(child.(ParsingExpression)) {
+				return false
+			}
+		}
+		return true
+
+	case *Choice:
+		for _, child := range e.Children {
+			if c.nullable(child.(ParsingExpression)) {
+				return true
+			}
+		}
+		return false
+
+	case *Repetition:
+		return !e.AtLeastOnce || c.nullable(e.Child)
+
+	case *Until:
+		return c.nullable(e.UntilExpression)
+
+	case *ParenthesizedExpression:
+		return c.nullable(e.Child)
+
+	case *OperatorPrecedence:
+		return c.nullable(e.Operand)
+
+	case *Label:
+		return c.nullable(e.Child)
+
+	case *ObjectCreator:
+		return c.nullable(e.Child)
+
+	default:
+		// lookaheads, predicates, actions and functions
+		return true
+	}
+}
+
+// loopsWithoutProgress reports whether an iteration of the loop generated for
+// expr can succeed without consuming any input, which would make the loop run
+// forever.
+func (c *Context) loopsWithoutProgress(expr ParsingExpression) bool {
+	switch e := expr.(type) {
+	case *Repetition:
+		return c.nullable(e.Child) && (e.GlueExpression == nil || c.nullable(e.GlueExpression))
+	case *Until:
+		return c.nullable(e.Child)
+	default:
+		return false
+	}
+}
+
+// exprPos returns the position of the first located expression in expr.
+func exprPos(expr ParsingExpression) token.Position {
+	var pos token.Position
+	walkExpr(expr, func(expr ParsingExpression) {
+		if pos.IsValid() {
+			return
+		}
+		switch e := expr.(type) {
+		case *RuleCall:
+			pos = e.Pos
+		case *StringTerminal:
+			pos = e.Pos
+		case *CharacterClassTerminal:
+			pos = e.Pos
+		case *PositivePredicate:
+			pos = e.Pos
+		case *NegativePredicate:
+			pos = e.Pos
+		case *Action:
+			pos = e.Pos
+		case *CommitFunction:
+			pos = e.Pos
+		}
+	})
+	return pos
+}
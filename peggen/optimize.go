@@ -0,0 +1,229 @@
+package peggen
+
+import "strconv"
+
+// maxInlineSize is the number of expressions up to which a rule is inlined at
+// optimization level 2.
+const maxInlineSize = 8
+
+// rawString holds the escaped characters of a StringTerminal created by the
+// optimizer.
+type rawString string
+
+func (s rawString) String() string {
+	return string(s)
+}
+
+// escapedString returns the characters of a StringTerminal matching s.
+func escapedString(s string) rawString {
+	quoted := strconv.Quote(s)
+	return rawString(quoted[1 : len(quoted)-1])
+}
+
+// optimize rewrites the rules according to the optimization level. Level 1
+// simplifies expressions, level 2 also inlines small rules and removes the
+// rules that are no longer called. Rules are not inlined when building a
+// concrete syntax tree or tracing, as each call becomes a node or is traced.
+func (c *Context) optimize() {
+	level := c.Options.OptimizationLevel
+	if level <= 0 {
+		return
+	}
+
+	reachable := c.reachableRules()
+	if level >= 2 && c.Options.Output != OutputCST && !c.Options.Trace {
+		for _, rule := range c.ruleList {
+			rule.Child = c.inlineCalls(rule.Child)
+		}
+	}
+	for _, rule := range c.ruleList {
+		rule.Child = c.simplify(rule.Child)
+	}
+	if level >= 2 {
+		stillReachable := c.reachableRules()
+		var rules []*Rule
+		for _, rule := range c.ruleList {
+			if reachable[rule] && !stillReachable[rule] {
+				delete(c.Rules, rule.RuleName.String())
+				continue
+			}
+			rules = append(rules, rule)
+		}
+		c.ruleList = rules
+	}
+}
+
+// simplify removes parentheses, choices and sequences with a single child and
+// nested sequences without output, and merges adjacent string terminals.
+func (c *Context) simplify(expr ParsingExpression) ParsingExpression {
+	switch e := expr.(type) {
+	case *ParenthesizedExpression:
+		return c.simplify(e.Child)
+
+	case *Choice:
+		for i, child := range e.Children {
+			e.Children[i] = c.simplify(child.(ParsingExpression))
+		}
+		if len(e.Children) == 1 {
+			return e.Children[0].(ParsingExpression)
+		}
+		return e
+
+	case *Sequence:
+		var children []interface{}
+		add := func(child ParsingExpression) {
+			if s, ok := child.(*StringTerminal); ok && len(children) != 0 {
+				if prev, ok := children[len(children)-1].(*StringTerminal); ok && prev.Fold == s.Fold {
+					// escapes may end at the boundary, e.g. '\0' '7'
+					prevValue, _ := stringTerminalValue(prev) // valid, see Context.check
+					value, _ := stringTerminalValue(s)
+					children[len(children)-1] = &StringTerminal{
+						Chars: escapedString(prevValue + value),
+						Fold:  s.Fold,
+						Pos:   prev.Pos,
+					}
+					return
+				}
+			}
+			children = append(children, child)
+		}
+		for _, child := range e.Children {
+			child := c.simplify(child.(ParsingExpression))
+			if inner, ok := child.(*Sequence); ok && !c.hasOutput(inner) {
+				for _, innerChild := range inner.Children {
+					add(innerChild.(ParsingExpression))
+				}
+				continue
+			}
+			add(child)
+		}
+		if len(children) == 1 {
+			return children[0].(ParsingExpression)
+		}
+		e.Children = children
+		return e
+
+	case *Repetition:
+		e.Child = c.simplify(e.Child)
+		if e.GlueExpression != nil {
+			e.GlueExpression = c.simplify(e.GlueExpression)
+		}
+	case *Until:
+		e.Child = c.simplify(e.Child)
+		e.UntilExpression = c.simplify(e.UntilExpression)
+	case *PositiveLookahead:
+		e.Child = c.simplify(e.Child)
+	case *NegativeLookahead:
+		e.Child = c.simplify(e.Child)
+	case *Label:
+		e.Child = c.simplify(e.Child)
+	case *ObjectCreator:
+		e.Child = c.simplify(e.Child)
+	case *OperatorPrecedence:
+		e.Operand = c.simplify(e.Operand)
+		for _, l := range e.Levels {
+			level := l.(*PrecedenceLevel)
+			for i, op := range level.Operators {
+				level.Operators[i] = c.simplify(op.(ParsingExpression))
+			}
+		}
+	}
+	return expr
+}
+
+// inlineCalls replaces calls of inlinable rules in expr by the rule bodies.
+func (c *Context) inlineCalls(expr ParsingExpression) ParsingExpression {
+	switch e := expr.(type) {
+	case *RuleCall:
+		if rule, ok := c.Rules[e.Name.String()]; ok && len(e.Arguments) == 0 && c.inlinable(rule) {
+			rule.Child = c.inlineCalls(rule.Child)
+			return rule.Child
+		}
+	case *Sequence:
+		for i, child := range e.Children {
+			e.Children[i] = c.inlineCalls(child.(ParsingExpression))
+		}
+	case *Choice:
+		for i, child := range e.Children {
+			e.Children[i] = c.inlineCalls(child.(ParsingExpression))
+		}
+	case *Repetition:
+		e.Child = c.inlineCalls(e.Child)
+		if e.GlueExpression != nil {
+			e.GlueExpression = c.inlineCalls(e.GlueExpression)
+		}
+	case *Until:
+		e.Child = c.inlineCalls(e.Child)
+		e.UntilExpression = c.inlineCalls(e.UntilExpression)
+	case *PositiveLookahead:
+		e.Child = c.inlineCalls(e.Child)
+	case *NegativeLookahead:
+		e.Child = c.inlineCalls(e.Child)
+	case *ParenthesizedExpression:
+		e.Child = c.inlineCalls(e.Child)
+	case *Label:
+		e.Child = c.inlineCalls(e.Child)
+	case *ObjectCreator:
+		e.Child = c.inlineCalls(e.Child)
+	case *OperatorPrecedence:
+		e.Operand = c.inlineCalls(e.Operand)
+		for _, l := range e.Levels {
+			level := l.(*PrecedenceLevel)
+			for i, op := range level.Operators {
+				level.Operators[i] = c.inlineCalls(op.(ParsingExpression))
+			}
+		}
+	}
+	return expr
+}
+
+// inlinable reports whether rule is small, not recursive and does not depend
+// on being a function of its own, i.e. has no parameters, local values or Go
+// code.
+func (c *Context) inlinable(rule *Rule) bool {
+	if len(rule.Parameters) != 0 || modifiesState(rule.Child) {
+		return false
+	}
+	size := 0
+	ok := true
+	walkExpr(rule.Child, func(expr ParsingExpression) {
+		size++
+		switch e := expr.(type) {
+		case *Label:
+			if e.IsLocal {
+				ok = false
+			}
+		case *RuleCall:
+			if len(e.Arguments) != 0 {
+				ok = false
+			}
+		case *PositivePredicate, *NegativePredicate, *Action, *SamedentFunction:
+			ok = false
+		}
+	})
+	return ok && size <= maxInlineSize && !c.callsItself(rule)
+}
+
+// callsItself reports whether rule can call itself, directly or indirectly.
+func (c *Context) callsItself(rule *Rule) bool {
+	visited := make(map[*Rule]bool)
+	var calls func(expr ParsingExpression) bool
+	calls = func(expr ParsingExpression) bool {
+		found := false
+		walkExpr(expr, func(expr ParsingExpression) {
+			call, ok := expr.(*RuleCall)
+			if !ok || found {
+				return
+			}
+			callee, ok := c.Rules[call.Name.String()]
+			if !ok || visited[callee] {
+				found = found || callee == rule
+				return
+			}
+			visited[callee] = true
+			found = callee == rule || calls(callee.Child)
+		})
+		return found
+	}
+	return calls(rule.Child)
+}
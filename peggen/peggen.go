@@ -1,16 +1,22 @@
 package peggen
 
 import (
-	"github.com/neelance/jetpeg"
+	"context"
+	"fmt"
 	"go/ast"
+	"go/token"
 	"reflect"
 	"strings"
+
+	"github.com/neelance/peg/peggen/internal/metagrammar"
+	"github.com/neelance/peg/peglib"
 )
 
-var metagrammarParser *jetpeg.Parser
+//go:generate go run github.com/neelance/peg generate -package metagrammar -entry Grammar -O 2 -limits -o internal/metagrammar/parser.go metagrammar.peg
+
+var typeMap = map[string]reflect.Type{}
 
 func init() {
-	typeMap := map[string]reflect.Type{}
 	addType := func(i interface{}) {
 		t := reflect.TypeOf(i)
 		typeMap[t.Name()] = t
@@ -23,8 +29,13 @@ func init() {
 	addType(Until{})
 	addType(PositiveLookahead{})
 	addType(NegativeLookahead{})
+	addType(PositivePredicate{})
+	addType(NegativePredicate{})
+	addType(Action{})
 	addType(RuleCall{})
 	addType(ParenthesizedExpression{})
+	addType(OperatorPrecedence{})
+	addType(PrecedenceLevel{})
 	addType(StringData{})
 	addType(BooleanData{})
 	addType(HashData{})
@@ -37,6 +48,10 @@ func init() {
 	addType(FalseFunction{})
 	addType(MatchFunction{})
 	addType(ErrorFunction{})
+	addType(IndentFunction{})
+	addType(SamedentFunction{})
+	addType(DedentFunction{})
+	addType(CommitFunction{})
 	addType(StringValue{})
 	addType(Label{})
 	addType(LocalValue{})
@@ -45,63 +60,163 @@ func init() {
 	addType(CharacterClassTerminal{})
 	addType(CharacterClassSingleCharacter{})
 	addType(CharacterClassRange{})
+}
 
-	jetpeg.Factory = func(name string, value interface{}) interface{} {
-		inst := reflect.New(typeMap[name])
-		for k, v := range value.(map[string]interface{}) {
-			if v == nil {
-				continue
-			}
-			k = strings.ToUpper(k[:1]) + k[1:]
-			f := inst.Elem().FieldByName(k)
-			if !f.IsValid() {
-				panic("no such field: " + k + " of " + name)
-			}
-			f.Set(reflect.ValueOf(v))
+// newObject creates the value of the class given by an object creator of the
+// metagrammar, setting its fields to the labelled values. Positions are
+// recorded with l.
+func newObject(class string, value interface{}, l *locator) interface{} {
+	inst := reflect.New(typeMap[class])
+	for k, v := range value.(map[string]interface{}) {
+		if v == nil {
+			continue
 		}
-		return inst.Interface()
-	}
-
-	var err error
-	metagrammarParser, err = jetpeg.Load("/Users/richard/gopath/src/github.com/neelance/peg/peggen/metagrammar.bc")
-	if err != nil {
-		panic(err)
+		k = strings.ToUpper(k[:1]) + k[1:]
+		f := inst.Elem().FieldByName(k)
+		if !f.IsValid() {
+			panic("no such field: " + k + " of " + class)
+		}
+		if pos, ok := f.Addr().Interface().(*token.Position); ok {
+			l.record(pos, v.(peglib.InputRange))
+			continue
+		}
+		f.Set(reflect.ValueOf(v))
 	}
+	return inst.Interface()
 }
 
 var byteSlice = &ast.ArrayType{Elt: ast.NewIdent("byte")}
 
-func Compile(grammar string) []ast.Decl {
-	g, err := metagrammarParser.Parse("Grammar", []byte(grammar))
+// Options controls code generation. A nil *Options is equivalent to the zero
+// value.
+type Options struct {
+	// Filename is the name of the grammar file. If set, Go code embedded in
+	// the grammar is preceded by line directives pointing back to it.
+	Filename string
+
+	// StateType is the Go type of peglib.UserState. If set, rules containing
+	// Go code get a local variable named state of this type.
+	// peglib.UserState must then be set before parsing, or these rules
+	// panic.
+	StateType string
+
+	// EntryRules are the rules called by users of the generated parser. If
+	// empty, all rules whose names start with an upper case letter are entry
+	// rules, or the first rule if there are none.
+	EntryRules []string
+
+	// OptimizationLevel selects how the rules are rewritten before code
+	// generation. At level 0 they are compiled as written, level 1 removes
+	// redundant parentheses, choices and sequences and merges adjacent
+	// strings, level 2 also inlines small rules.
+	OptimizationLevel int
+
+	// Output selects what the generated rules produce besides the end of the
+	// matched input.
+	Output OutputMode
+
+	// Memoize makes the rules remember their results at each position of a
+	// peglib.Document, which can then be reparsed incrementally after edits.
+	// It requires the CST or recognize-only output and rules without Go
+	// code or indentation functions.
+	Memoize bool
+
+	// Streaming makes the rules record the input they examine, so that
+	// peglib.Stream can tell whether a parse depends on input that has not
+	// been read yet. It cannot be combined with Memoize.
+	Streaming bool
+
+	// Limits makes the rules count their invocations and nesting, so that
+	// peglib.ParseContext can enforce its limits and cancel parsing.
+	Limits bool
+
+	// Trace makes the rules report their invocations to peglib.Trace. Rules
+	// are not inlined, so that all of them are traced.
+	Trace bool
+}
+
+// OutputMode selects how generated rules build their output.
+type OutputMode int
+
+const (
+	// OutputValues builds objects of labelled values, arrays and input
+	// ranges on the peglib output stack, see peglib.OutputBuilder.
+	OutputValues OutputMode = iota
+
+	// OutputLight builds the same values as OutputValues with less work per
+	// array element. The elements of an array are collected on the output
+	// stack and combined once at the end of the repetition.
+	OutputLight
+
+	// OutputCST builds a lossless concrete syntax tree of peglib.Node
+	// values. Each rule invocation becomes a node, labels are ignored.
+	OutputCST
+
+	// OutputNone only recognizes the input. The generated code does not use
+	// the output stack at all; variables of labels referenced by Go code
+	// hold the matched input range.
+	OutputNone
+)
+
+func Compile(grammar string, opts *Options) []ast.Decl {
+	c, err := parse(grammar, opts)
 	if err != nil {
 		panic(err)
 	}
-
-	c := &Context{
-		Rules: make(map[string]*Rule),
+	if errs := c.check().Errors(); len(errs) != 0 {
+		panic(errs)
 	}
-	var ruleNames []string
+	c.optimize()
 
 	var decls []ast.Decl
-	for _, data := range g.(map[string]interface{})["Rules"].([]interface{}) {
-		d := data.(map[string]interface{})
-		rule := d["Child"].(*Rule)
-		rule.RuleName = d["Name"].(jetpeg.Stringer)
-		// rule.Parameters = d["Parameters"].([]interface{})
-		c.Rules[rule.RuleName.String()] = rule
-		ruleNames = append(ruleNames, rule.RuleName.String())
-	}
-
-	for _, name := range ruleNames {
-		body := c.compileExpr(c.Rules[name].Child, func() []ast.Stmt {
-			return []ast.Stmt{
-				&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent("nil")}},
+	for i, rule := range c.ruleList {
+		var body []ast.Stmt
+		c.labelVars = nil
+		if containsCode(rule.Child) {
+			body = c.codePrologue(rule.Child)
+		}
+		ruleStart := newIdent("ruleStart")
+		var failureResult, successResult ast.Expr = ast.NewIdent("nil"), input
+		var successPrologue []ast.Stmt
+		if c.Options.Memoize {
+			rest, ok, ruleMemo := ast.NewIdent("rest"), ast.NewIdent("ok"), newIdent("ruleMemo")
+			body = append(body,
+				&ast.IfStmt{
+					Init: &ast.AssignStmt{Lhs: []ast.Expr{rest, ok}, Tok: token.DEFINE, Rhs: []ast.Expr{peglibCall("MemoLookup", intConst(i), input)}},
+					Cond: ok,
+					Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{rest}}}},
+				},
+				simpleDefine(ruleStart, input),
+				simpleDefine(ruleMemo, peglibCall("MemoEnter", input)),
+			)
+			failureResult = peglibCall("MemoFailure", intConst(i), ruleMemo, ruleStart)
+			successResult = peglibCall("MemoSuccess", intConst(i), ruleMemo, ruleStart, input)
+		}
+		body = append(body, c.enter()...)
+		if c.Options.Output == OutputCST {
+			// the nodes of the rules called since ruleMark become children
+			ruleMark := newIdent("ruleMark")
+			if !c.Options.Memoize {
+				body = append(body, simpleDefine(ruleStart, input))
 			}
-		})
-		body = append(body, &ast.ReturnStmt{Results: []ast.Expr{input}})
+			body = append(body, simpleDefine(ruleMark, peglibCall("Mark")))
+			successPrologue = []ast.Stmt{exprStmt(peglibCall("MakeNode", stringConst(rule.RuleName.String()), ruleMark, ruleStart, input))}
+		}
+		if c.Options.Trace {
+			name, tracePos := stringConst(rule.RuleName.String()), newIdent("tracePos")
+			body = append(body, simpleDefine(tracePos, peglibCall("TraceEnter", name, input)))
+			failureResult = peglibCall("TraceLeave", name, tracePos, failureResult)
+			successResult = peglibCall("TraceLeave", name, tracePos, successResult)
+		}
+		failure := func() []ast.Stmt {
+			return []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{c.leave(failureResult)}}}
+		}
+		success := append(successPrologue, &ast.ReturnStmt{Results: []ast.Expr{c.leave(successResult)}})
+		body = append(body, c.compileExpr(rule.Child, failure)...)
+		body = append(body, success...)
 
 		decls = append(decls, &ast.FuncDecl{
-			Name: ast.NewIdent(name),
+			Name: ast.NewIdent(rule.RuleName.String()),
 			Type: &ast.FuncType{
 				Params:  &ast.FieldList{List: []*ast.Field{&ast.Field{Names: []*ast.Ident{input}, Type: byteSlice}}},
 				Results: &ast.FieldList{List: []*ast.Field{&ast.Field{Type: byteSlice}}},
@@ -109,5 +224,54 @@ func Compile(grammar string) []ast.Decl {
 			Body: &ast.BlockStmt{List: body},
 		})
 	}
-	return decls
+	return append(decls, c.helperDecls...)
+}
+
+// parse parses grammar with the metagrammar. The returned Context holds the
+// rules in source order, with the first definition of each name in Rules.
+func parse(grammar string, opts *Options) (*Context, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	l := &locator{filename: opts.Filename, src: grammar}
+	peglib.ParseMutex.Lock()
+	b := &peglib.ValueBuilder{Factory: func(class string, value interface{}) interface{} {
+		return newObject(class, value, l)
+	}}
+	err := peglib.ParseContextBuilder(context.Background(), metagrammar.Grammar, []byte(grammar), peglib.Limits{MaxDepth: 10000}, b)
+	peglib.ParseMutex.Unlock()
+	if err != nil {
+		pos := token.Position{Filename: opts.Filename}
+		if e, ok := err.(*peglib.ParsingError); ok {
+			pos = l.position(e.Position)
+		}
+		return nil, Diagnostics{{Pos: pos, Severity: Error, Message: fmt.Sprintf("syntax error: %v", err)}}
+	}
+	g := b.Result().(map[string]interface{})
+	end := g["End"].(peglib.InputRange)
+	l.resolve(end)
+
+	c := &Context{
+		Rules:         make(map[string]*Rule),
+		Options:       opts,
+		byteSetTables: make(map[byteSet]*ast.Ident),
+	}
+	for _, data := range g["Rules"].([]interface{}) {
+		d := data.(map[string]interface{})
+		rule := d["Child"].(*Rule)
+		rule.RuleName = d["Name"].(fmt.Stringer)
+		rule.Parameters, _ = d["Parameters"].([]interface{})
+		rule.Pos = l.rangePosition(d["Pos"].(peglib.InputRange), end)
+		if _, ok := c.Rules[rule.RuleName.String()]; !ok {
+			c.Rules[rule.RuleName.String()] = rule
+		}
+		c.ruleList = append(c.ruleList, rule)
+		if modifiesState(rule.Child) {
+			c.usesState = true
+		}
+	}
+	c.computeNullable()
+	c.computeFirst()
+	return c, nil
 }
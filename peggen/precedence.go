@@ -0,0 +1,145 @@
+package peggen
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// compilePrecedence returns a function that parses e by precedence climbing.
+// Levels are listed from the loosest to the tightest binding operators. The
+// function takes the input and the lowest level whose operators it may use,
+// so that a single call handles an operand together with all operators that
+// bind tighter than its caller's. Prefix operators may start any operand; the
+// operand of a prefix operator extends over the operators binding tighter
+// than it.
+func (c *Context) compilePrecedence(name *ast.Ident, e *OperatorPrecedence) ast.Decl {
+	labelVars := c.labelVars
+	c.labelVars = nil
+	defer func() { c.labelVars = labelVars }()
+
+	output := c.buildsValues()
+	minLevel := ast.NewIdent("minLevel")
+	operandEnd := ast.NewIdent("operandEnd")
+	climb := func(level int) ast.Expr {
+		return &ast.CallExpr{Fun: name, Args: []ast.Expr{input, intConst(level)}}
+	}
+	levelAllowed := func(level int) ast.Expr {
+		return &ast.BinaryExpr{X: minLevel, Op: token.LEQ, Y: intConst(level)}
+	}
+	// tryOperator matches op, pushes its input range and continues with then.
+	// If op does not match, the input is restored to before.
+	tryOperator := func(op ParsingExpression, before *backtrackPoint, then []ast.Stmt) *ast.BlockStmt {
+		nextOperator := newDynamicLabel("nextOperator")
+		block := c.compileExpr(op, nextOperator.GotoSlice)
+		if c.hasOutput(op) {
+			block = append(block, exprStmt(peglibCall("Pop", intConst(1))))
+		}
+		if output {
+			block = append(block, exprStmt(peglibCall("PushInputRange", before.input, input)))
+		}
+		block = append(block, then...)
+		body := []ast.Stmt{&ast.BlockStmt{List: block}}
+		restore := before.Restore()
+		body = append(body, nextOperator.WithLabel(restore[0]))
+		body = append(body, restore[1:]...)
+		return &ast.BlockStmt{List: body}
+	}
+	// tryOperatorAt only tries op if operators of the given level may be
+	// used by the current call.
+	tryOperatorAt := func(level int, op ParsingExpression, before *backtrackPoint, then []ast.Stmt) ast.Stmt {
+		return &ast.IfStmt{Cond: levelAllowed(level), Body: tryOperator(op, before, then)}
+	}
+	// withOperand parses the operand of an operator at the given level and
+	// combines it using makeFun, then continues with success. If no operand
+	// follows, the operator is popped again.
+	withOperand := func(level int, makeFun string, success ast.Stmt) []ast.Stmt {
+		matched := []ast.Stmt{simpleAssign(input, operandEnd)}
+		if output {
+			matched = append(matched, exprStmt(peglibCall(makeFun)))
+		}
+		stmts := []ast.Stmt{
+			simpleDefine(operandEnd, climb(level)),
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: operandEnd, Op: token.NEQ, Y: ast.NewIdent("nil")},
+				Body: &ast.BlockStmt{List: append(matched, success)},
+			},
+		}
+		if output {
+			stmts = append(stmts, exprStmt(peglibCall("Pop", intConst(1))))
+		}
+		return stmts
+	}
+
+	operandDone := newDynamicLabel("operandDone")
+	beforeOperand := c.newBacktrackPoint("beforeOperand")
+	var prefixOperators []ast.Stmt
+	for i, l := range e.Levels {
+		level := l.(*PrecedenceLevel)
+		if level.Kind.String() != "prefix" {
+			continue
+		}
+		for _, op := range level.Operators {
+			prefixOperators = append(prefixOperators, tryOperator(op.(ParsingExpression), beforeOperand, withOperand(i, "MakePrefix", operandDone.Goto())))
+		}
+	}
+	operand := c.compileExpr(e.Operand, func() []ast.Stmt {
+		return []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{c.leave(ast.NewIdent("nil"))}}}
+	})
+	operandRange := output && !c.hasOutput(e.Operand)
+	if operandRange {
+		operand = append(operand, exprStmt(peglibCall("PushInputRange", beforeOperand.input, input)))
+	}
+	body := c.enter()
+	switch {
+	case len(prefixOperators) != 0:
+		body = append(body, beforeOperand.Save()...)
+	case operandRange:
+		// the state is only restored after a prefix operator
+		beforeOperand.state = nil
+		beforeOperand.mark = nil
+		body = append(body, beforeOperand.Save()...)
+	}
+	body = append(body, prefixOperators...)
+	body = append(body, &ast.BlockStmt{List: operand})
+
+	beforeOperator := c.newBacktrackPoint("beforeOperator")
+	var loop []ast.Stmt
+	for i, l := range e.Levels {
+		level := l.(*PrecedenceLevel)
+		for _, op := range level.Operators {
+			switch level.Kind.String() {
+			case "postfix":
+				then := []ast.Stmt{&ast.BranchStmt{Tok: token.CONTINUE}}
+				if output {
+					then = append([]ast.Stmt{exprStmt(peglibCall("MakePostfix"))}, then...)
+				}
+				loop = append(loop, tryOperatorAt(i, op.(ParsingExpression), beforeOperator, then))
+			case "left":
+				loop = append(loop, tryOperatorAt(i, op.(ParsingExpression), beforeOperator, withOperand(i+1, "MakeInfix", &ast.BranchStmt{Tok: token.CONTINUE})))
+			case "right":
+				loop = append(loop, tryOperatorAt(i, op.(ParsingExpression), beforeOperator, withOperand(i, "MakeInfix", &ast.BranchStmt{Tok: token.CONTINUE})))
+			}
+		}
+	}
+	if len(loop) != 0 {
+		// with prefix operators only, nothing follows the operand
+		loop = append(beforeOperator.Save(), loop...)
+	}
+	loop = append(loop, &ast.BranchStmt{Tok: token.BREAK})
+	body = append(body,
+		operandDone.WithLabel(&ast.ForStmt{Body: &ast.BlockStmt{List: loop}}),
+		&ast.ReturnStmt{Results: []ast.Expr{c.leave(input)}},
+	)
+
+	return &ast.FuncDecl{
+		Name: name,
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				&ast.Field{Names: []*ast.Ident{input}, Type: byteSlice},
+				&ast.Field{Names: []*ast.Ident{minLevel}, Type: ast.NewIdent("int")},
+			}},
+			Results: &ast.FieldList{List: []*ast.Field{&ast.Field{Type: byteSlice}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}
+}
@@ -0,0 +1,191 @@
+package peggen
+
+import (
+	"strconv"
+)
+
+// shadowing is an alternative of an ordered choice that can never be
+// selected because of an earlier alternative.
+type shadowing struct {
+	Alternative ParsingExpression
+	By          ParsingExpression
+	Unreachable bool // By always succeeds, otherwise By matches a prefix of Alternative
+}
+
+// shadowedAlternatives finds the alternatives of e that are never selected:
+// those following an alternative that always succeeds and, for alternatives
+// made of literals and character classes, those of which every match starts
+// with a match of an earlier alternative.
+func shadowedAlternatives(e *Choice) []shadowing {
+	alternatives := toExpressions(e.Children)
+	var found []shadowing
+	for i, alt := range alternatives {
+		for _, earlier := range alternatives[:i] {
+			if alwaysSucceeds(earlier) {
+				found = append(found, shadowing{Alternative: alt, By: earlier, Unreachable: true})
+				break
+			}
+			if matchesPrefix(earlier, alt) {
+				found = append(found, shadowing{Alternative: alt, By: earlier})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// matchesPrefix reports whether a matches a prefix of every input matched
+// by b.
+func matchesPrefix(a, b ParsingExpression) bool {
+	aSets, ok := literalSets(a)
+	if !ok {
+		return false
+	}
+	bSets, ok := literalSets(b)
+	if !ok || len(aSets) > len(bSets) {
+		return false
+	}
+	for i := range aSets {
+		if !bSets[i].isSubsetOf(&aSets[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// literalSets returns the bytes matched by expr, one set for each input byte,
+// if expr consists only of literals and character classes.
+func literalSets(expr ParsingExpression) ([]byteSet, bool) {
+	switch e := expr.(type) {
+	case *StringTerminal:
+		str, err := stringTerminalValue(e)
+		if err != nil {
+			return nil, false
+		}
+		sets := make([]byteSet, len(str))
+		for i := 0; i < len(str); i++ {
+			b := str[i]
+			sets[i].add(b)
+			if e.Fold {
+				switch {
+				case b >= 'a' && b <= 'z':
+					sets[i].add(b - 'a' + 'A')
+				case b >= 'A' && b <= 'Z':
+					sets[i].add(b - 'A' + 'a')
+				}
+			}
+		}
+		return sets, true
+
+	case *CharacterClassTerminal:
+		set, err := charClassSet(e)
+		if err != nil {
+			return nil, false
+		}
+		return []byteSet{set}, true
+
+	case *Sequence:
+		var sets []byteSet
+		for _, child := range e.Children {
+			childSets, ok := literalSets(child.(ParsingExpression))
+			if !ok {
+				return nil, false
+			}
+			sets = append(sets, childSets...)
+		}
+		return sets, true
+
+	case *ParenthesizedExpression:
+		return literalSets(e.Child)
+
+	case *Label:
+		return literalSets(e.Child)
+
+	case *ObjectCreator:
+		return literalSets(e.Child)
+
+	default:
+		return nil, false
+	}
+}
+
+// alwaysSucceeds reports whether expr succeeds on any input. Rule calls are
+// assumed to be able to fail.
+func alwaysSucceeds(expr ParsingExpression) bool {
+	switch e := expr.(type) {
+	case *EmptyParsingExpression, *Action, *TrueFunction, *FalseFunction, *DedentFunction, *CommitFunction:
+		return true
+
+	case *StringTerminal:
+		return e.Chars.String() == ""
+
+	case *Sequence:
+		for _, child := range e.Children {
+			if !alwaysSucceeds(child.(ParsingExpression)) {
+				return false
+			}
+		}
+		return true
+
+	case *Choice:
+		for _, child := range e.Children {
+			if alwaysSucceeds(child.(ParsingExpression)) {
+				return true
+			}
+		}
+		return false
+
+	case *Repetition:
+		return !e.AtLeastOnce || alwaysSucceeds(e.Child)
+
+	case *PositiveLookahead:
+		return alwaysSucceeds(e.Child)
+
+	case *ParenthesizedExpression:
+		return alwaysSucceeds(e.Child)
+
+	case *Label:
+		return alwaysSucceeds(e.Child)
+
+	case *ObjectCreator:
+		return alwaysSucceeds(e.Child)
+
+	default:
+		return false
+	}
+}
+
+// stringTerminalValue returns the characters matched by e.
+func stringTerminalValue(e *StringTerminal) (string, error) {
+	return unescape(e.Chars.String())
+}
+
+// unescape returns the value of the characters of a string in a grammar. The
+// escape sequences are those of Go, plus \' and \0.
+func unescape(chars string) (string, error) {
+	quoted := []byte{'"'}
+	for i := 0; i < len(chars); i++ {
+		switch {
+		case chars[i] == '"':
+			quoted = append(quoted, '\\', '"')
+		case chars[i] == '\\' && i+1 < len(chars):
+			i++
+			switch {
+			case chars[i] == '\'':
+				quoted = append(quoted, '\'')
+			case chars[i] == '0' && (i+1 == len(chars) || chars[i+1] < '0' || chars[i+1] > '7'):
+				quoted = append(quoted, `\x00`...)
+			default:
+				quoted = append(quoted, '\\', chars[i])
+			}
+		default:
+			quoted = append(quoted, chars[i])
+		}
+	}
+	quoted = append(quoted, '"')
+	str, err := strconv.Unquote(string(quoted))
+	if err != nil {
+		return "", errInvalidEscape
+	}
+	return str, nil
+}
@@ -1,15 +1,19 @@
 package peggen
 
 import (
-	"github.com/neelance/jetpeg"
+	"fmt"
+	"go/token"
 )
 
 type Rule struct {
-	RuleName            jetpeg.Stringer
+	RuleName            fmt.Stringer
+	Pos                 token.Position
 	Parameters          []interface{}
 	Child               ParsingExpression
 	HasOutput           bool
 	HasOutputCalculated bool
+	Nullable            bool
+	First               byteSet
 }
 
 type ParsingExpression interface{}
@@ -17,22 +21,24 @@ type ParsingExpression interface{}
 type EmptyParsingExpression struct{}
 
 type StringTerminal struct {
-	Chars jetpeg.Stringer
+	Chars fmt.Stringer
 	Fold  bool
+	Pos   token.Position
 }
 
 type CharacterClassTerminal struct {
 	Selections []interface{}
 	Inverted   bool
+	Pos        token.Position
 }
 
 type CharacterClassSingleCharacter struct {
-	Char jetpeg.Stringer
+	Char fmt.Stringer
 }
 
 type CharacterClassRange struct {
-	BeginChar jetpeg.Stringer
-	EndChar   jetpeg.Stringer
+	BeginChar fmt.Stringer
+	EndChar   fmt.Stringer
 }
 
 type Sequence struct {
@@ -62,9 +68,35 @@ type NegativeLookahead struct {
 	Child ParsingExpression
 }
 
+type PositivePredicate struct {
+	Code fmt.Stringer
+	Pos  token.Position
+}
+
+type NegativePredicate struct {
+	Code fmt.Stringer
+	Pos  token.Position
+}
+
+type Action struct {
+	Code fmt.Stringer
+	Pos  token.Position
+}
+
 type RuleCall struct {
-	Name      jetpeg.Stringer
+	Name      fmt.Stringer
 	Arguments []interface{}
+	Pos       token.Position
+}
+
+type OperatorPrecedence struct {
+	Operand ParsingExpression
+	Levels  []interface{}
+}
+
+type PrecedenceLevel struct {
+	Kind      fmt.Stringer
+	Operators []interface{}
 }
 
 type ParenthesizedExpression struct {
@@ -72,18 +104,19 @@ type ParenthesizedExpression struct {
 }
 
 type Label struct {
-	Name    jetpeg.Stringer
+	Name    fmt.Stringer
 	IsLocal bool
 	Child   ParsingExpression
+	Pos     token.Position
 }
 
 type LocalValue struct {
-	Name jetpeg.Stringer
+	Name fmt.Stringer
 }
 
 type ObjectCreator struct {
 	Child     ParsingExpression
-	ClassName jetpeg.Stringer
+	ClassName fmt.Stringer
 	Data      interface{}
 }
 
@@ -98,15 +131,28 @@ type MatchFunction struct {
 }
 
 type ErrorFunction struct {
-	Msg jetpeg.Stringer
+	Msg fmt.Stringer
+}
+
+type IndentFunction struct {
+}
+
+type SamedentFunction struct {
+}
+
+type DedentFunction struct {
+}
+
+type CommitFunction struct {
+	Pos token.Position
 }
 
 type StringValue struct {
-	String jetpeg.Stringer
+	String fmt.Stringer
 }
 
 type StringData struct {
-	String jetpeg.Stringer
+	String fmt.Stringer
 }
 
 type BooleanData struct {
@@ -118,7 +164,7 @@ type HashData struct {
 }
 
 type HashDataEntry struct {
-	Label jetpeg.Stringer
+	Label fmt.Stringer
 	Data  interface{}
 }
 
@@ -131,10 +177,61 @@ type ArrayDataEntry struct {
 }
 
 type ObjectData struct {
-	ClassName jetpeg.Stringer
+	ClassName fmt.Stringer
 	Data      interface{}
 }
 
 type LabelData struct {
-	Name jetpeg.Stringer
+	Name fmt.Stringer
+}
+
+// subExpressions returns the direct children of expr in source order.
+func subExpressions(expr ParsingExpression) []ParsingExpression {
+	switch e := expr.(type) {
+	case *Sequence:
+		return toExpressions(e.Children)
+	case *Choice:
+		return toExpressions(e.Children)
+	case *Repetition:
+		if e.GlueExpression != nil {
+			return []ParsingExpression{e.Child, e.GlueExpression}
+		}
+		return []ParsingExpression{e.Child}
+	case *Until:
+		return []ParsingExpression{e.Child, e.UntilExpression}
+	case *PositiveLookahead:
+		return []ParsingExpression{e.Child}
+	case *NegativeLookahead:
+		return []ParsingExpression{e.Child}
+	case *ParenthesizedExpression:
+		return []ParsingExpression{e.Child}
+	case *OperatorPrecedence:
+		exprs := []ParsingExpression{e.Operand}
+		for _, level := range e.Levels {
+			exprs = append(exprs, toExpressions(level.(*PrecedenceLevel).Operators)...)
+		}
+		return exprs
+	case *Label:
+		return []ParsingExpression{e.Child}
+	case *ObjectCreator:
+		return []ParsingExpression{e.Child}
+	default:
+		return nil
+	}
+}
+
+func toExpressions(children []interface{}) []ParsingExpression {
+	exprs := make([]ParsingExpression, len(children))
+	for i, child := range children {
+		exprs[i] = child.(ParsingExpression)
+	}
+	return exprs
+}
+
+// walkExpr calls fn for expr and all of its descendants in source order.
+func walkExpr(expr ParsingExpression, fn func(ParsingExpression)) {
+	fn(expr)
+	for _, child := range subExpressions(expr) {
+		walkExpr(child, fn)
+	}
 }
@@ -0,0 +1,75 @@
+package peglib
+
+import "fmt"
+
+// Node is a node of a concrete syntax tree, as built by parsers generated with
+// the CST output mode. A node is either a rule invocation or a token, i.e. the
+// input matched by a rule between the invocations of other rules. Tokens have
+// an empty Rule and no children. The texts of the children of a node add up
+// to the text of the node, so the tokens of a tree reproduce the input.
+type Node struct {
+	Rule     string     `json:",omitempty"`
+	Text     InputRange // the exact input matched by the node
+	Children []*Node    `json:",omitempty"`
+}
+
+// Tokens calls f with the tokens below n in input order.
+func (n *Node) Tokens(f func(token *Node)) {
+	if n.Rule == "" {
+		f(n)
+		return
+	}
+	for _, c := range n.Children {
+		c.Tokens(f)
+	}
+}
+
+// Mark returns the height of the output stack, to be passed to Rewind or
+// MakeNode.
+func Mark() int {
+	return len(outputStack)
+}
+
+// Rewind removes the values pushed since mark was taken.
+func Rewind(mark int) {
+	if Debug {
+		fmt.Printf("Rewind(%d)\n", mark)
+	}
+	popOutput(len(outputStack) - mark)
+}
+
+// MakeNode replaces the nodes pushed since mark by a node of rule spanning
+// from startInput to endInput. Input between the nodes becomes tokens.
+func MakeNode(rule string, mark int, startInput, endInput []byte) {
+	if Debug {
+		fmt.Printf("MakeNode(%q, %d)\n", rule, mark)
+	}
+	text := InputRange(startInput[:len(startInput)-len(endInput)])
+	var children []*Node
+	pos := 0
+	for _, e := range outputStack[mark:] {
+		child := nodes[e.root].value.(*Node)
+		// the texts on the output stack end at the same buffer, so their
+		// capacities give offsets
+		offset := cap(text) - cap(nodes[e.root].text)
+		if offset > pos {
+			children = append(children, &Node{Text: text[pos:offset]})
+		}
+		children = append(children, child)
+		pos = offset + len(child.Text)
+	}
+	if pos < len(text) {
+		children = append(children, &Node{Text: text[pos:]})
+	}
+	checkOutput(len(children) + 1)
+	cstNodes += len(children) + 1
+	popOutput(len(outputStack) - mark)
+	pushNodeValue(&Node{Rule: rule, Text: text, Children: children}, text)
+}
+
+// pushNodeValue pushes n, which matched text of the current input.
+func pushNodeValue(n *Node, text InputRange) {
+	v := pushNode(valueNode)
+	v.value = n
+	v.text = text
+}
@@ -0,0 +1,270 @@
+package peglib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Debugger is a Tracer that stops parsers generated with the trace option
+// when a rule starts or ends and reads commands, e.g. to step through the
+// rule invocations, set breakpoints on rules or input offsets and inspect
+// the output and locals stacks. Type help at the prompt for the commands.
+type Debugger struct {
+	input   []byte
+	in      *bufio.Scanner
+	out     io.Writer
+	rules   map[string]bool // breakpoints
+	offsets map[int]bool    // breakpoints
+	stack   []debugFrame
+	mode    debugMode
+	target  int // depth for debugNext and debugOut
+	last    string
+}
+
+type debugFrame struct {
+	rule string
+	pos  int
+}
+
+type debugMode int
+
+const (
+	debugStep     debugMode = iota // stop at the next event
+	debugNext                      // stop at the next event not deeper than target
+	debugOut                       // stop at the next end of a rule not deeper than target
+	debugContinue                  // stop at breakpoints only
+	debugDetached                  // never stop again
+)
+
+const debugHelp = `commands:
+  s, step           stop at the next start or end of a rule
+  n, next           step over the rules called by the current rule
+  o, out            run until the current rule ends
+  c, continue       run until a breakpoint
+  b, break [rule|@offset]
+                    set a breakpoint, or list the breakpoints
+  d, delete rule|@offset
+                    delete a breakpoint
+  bt, stack         show the running rules
+  p, pos            show the current position
+  output            show the values on the output stack
+  locals            show the locals stack
+  q, quit           stop debugging and let the parse finish
+An empty line repeats the last command.
+`
+
+// NewDebugger returns a Debugger for parsing input, which reads commands from
+// in and writes to out. It stops at the first rule invocation.
+func NewDebugger(input []byte, in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		input:   input,
+		in:      bufio.NewScanner(in),
+		out:     out,
+		rules:   make(map[string]bool),
+		offsets: make(map[int]bool),
+	}
+}
+
+// Break sets a breakpoint on the invocations of a rule, or on all rule
+// invocations at an offset if the argument has the form @offset.
+func (d *Debugger) Break(breakpoint string) error {
+	if offset, ok, err := parseOffset(breakpoint); ok {
+		if err != nil {
+			return err
+		}
+		d.offsets[offset] = true
+		return nil
+	}
+	d.rules[breakpoint] = true
+	return nil
+}
+
+// Continue makes the debugger stop at breakpoints only.
+func (d *Debugger) Continue() {
+	d.mode = debugContinue
+}
+
+func parseOffset(breakpoint string) (int, bool, error) {
+	if !strings.HasPrefix(breakpoint, "@") {
+		return 0, false, nil
+	}
+	offset, err := strconv.Atoi(breakpoint[1:])
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid offset %q", breakpoint[1:])
+	}
+	return offset, true, nil
+}
+
+func (d *Debugger) Enter(rule string, pos int) {
+	d.stack = append(d.stack, debugFrame{rule: rule, pos: pos})
+	stop := false
+	switch d.mode {
+	case debugStep:
+		stop = true
+	case debugNext:
+		stop = len(d.stack) <= d.target
+	}
+	if d.mode != debugDetached && (d.rules[rule] || d.offsets[pos]) {
+		stop = true
+	}
+	if stop {
+		fmt.Fprintf(d.out, "%s-> %s at %s\n", d.indent(), rule, d.describe(pos))
+		d.prompt(false)
+	}
+}
+
+func (d *Debugger) Leave(rule string, pos, end int, ok bool) {
+	stop := false
+	switch d.mode {
+	case debugStep:
+		stop = true
+	case debugNext, debugOut:
+		stop = len(d.stack) <= d.target
+	}
+	if stop {
+		if ok {
+			fmt.Fprintf(d.out, "%s<- %s matched %d-%d %q\n", d.indent(), rule, pos, end, d.input[pos:end])
+		} else {
+			fmt.Fprintf(d.out, "%s<- %s failed at %d\n", d.indent(), rule, pos)
+		}
+		d.prompt(true)
+	}
+	d.stack = d.stack[:len(d.stack)-1]
+}
+
+func (d *Debugger) indent() string {
+	return strings.Repeat("  ", len(d.stack)-1)
+}
+
+// describe returns the line and column of pos and the input following it.
+func (d *Debugger) describe(pos int) string {
+	before := d.input[:pos]
+	line := bytes.Count(before, []byte{'\n'}) + 1
+	column := pos - bytes.LastIndexByte(before, '\n')
+	rest := d.input[pos:]
+	if len(rest) > 20 {
+		return fmt.Sprintf("%d (line %d, column %d): %q...", pos, line, column, rest[:20])
+	}
+	return fmt.Sprintf("%d (line %d, column %d): %q", pos, line, column, rest)
+}
+
+// prompt reads commands until one of them resumes parsing. leaving tells
+// whether the current rule is ending.
+func (d *Debugger) prompt(leaving bool) {
+	for {
+		fmt.Fprint(d.out, "(peg) ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			d.mode = debugDetached
+			return
+		}
+		line := strings.TrimSpace(d.in.Text())
+		if line == "" {
+			line = d.last
+		}
+		d.last = line
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		args := fields[1:]
+		switch fields[0] {
+		case "s", "step":
+			d.mode = debugStep
+			return
+		case "n", "next":
+			d.mode = debugNext
+			d.target = len(d.stack)
+			return
+		case "o", "out":
+			d.mode = debugOut
+			d.target = len(d.stack)
+			if leaving {
+				d.target--
+			}
+			return
+		case "c", "continue":
+			d.mode = debugContinue
+			return
+		case "q", "quit":
+			d.mode = debugDetached
+			return
+		case "b", "break":
+			if len(args) == 0 {
+				d.listBreakpoints()
+				continue
+			}
+			for _, b := range args {
+				if err := d.Break(b); err != nil {
+					fmt.Fprintln(d.out, err)
+				}
+			}
+		case "d", "delete":
+			for _, b := range args {
+				if offset, ok, err := parseOffset(b); ok {
+					if err != nil {
+						fmt.Fprintln(d.out, err)
+						continue
+					}
+					delete(d.offsets, offset)
+					continue
+				}
+				delete(d.rules, b)
+			}
+		case "bt", "stack":
+			for i := len(d.stack) - 1; i >= 0; i-- {
+				fmt.Fprintf(d.out, "%s at %d\n", d.stack[i].rule, d.stack[i].pos)
+			}
+		case "p", "pos":
+			f := d.stack[len(d.stack)-1]
+			fmt.Fprintf(d.out, "%s started at %s\n", f.rule, d.describe(f.pos))
+		case "output":
+			for i, v := range outputValues() {
+				fmt.Fprintf(d.out, "%d: %s\n", i, debugJSON(v))
+			}
+		case "locals":
+			for i, v := range localsStack {
+				fmt.Fprintf(d.out, "%d: %s\n", i, debugJSON(v))
+			}
+		case "h", "help":
+			fmt.Fprint(d.out, debugHelp)
+		default:
+			fmt.Fprintf(d.out, "unknown command %q, type help for a list\n", fields[0])
+		}
+	}
+}
+
+func (d *Debugger) listBreakpoints() {
+	var list []string
+	for rule := range d.rules {
+		list = append(list, rule)
+	}
+	sort.Strings(list)
+	var offsets []int
+	for offset := range d.offsets {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	for _, offset := range offsets {
+		list = append(list, "@"+strconv.Itoa(offset))
+	}
+	if len(list) == 0 {
+		fmt.Fprintln(d.out, "no breakpoints")
+		return
+	}
+	fmt.Fprintln(d.out, strings.Join(list, " "))
+}
+
+func debugJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,230 @@
+package peglib
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Limits bounds the resources used by ParseContext. A zero field means no
+// limit.
+type Limits struct {
+	// MaxDepth is the maximum nesting of rule invocations, which bounds the
+	// stack used by the parser.
+	MaxDepth int
+
+	// MaxSteps is the maximum number of rule invocations, which bounds the
+	// time spent on backtracking.
+	MaxSteps int
+
+	// MaxOutput is the maximum number of output nodes, i.e. values, labels
+	// and array elements held by the output stack at once. The nodes of a
+	// concrete syntax tree count when they are built, even if they are
+	// discarded later.
+	MaxOutput int
+
+	// Strict makes ParseContext refuse rules generated without the limits
+	// option with ErrNoLimits.
+	Strict bool
+}
+
+// ErrNoLimits is returned by ParseContext with Limits.Strict if the rule was
+// not generated with the limits option.
+var ErrNoLimits = errors.New("peglib: rule not generated with the limits option")
+
+// DepthError is returned by ParseContext if rule invocations nest deeper than
+// Limits.MaxDepth.
+type DepthError struct {
+	Offset int // of the input of the innermost rule invocation
+}
+
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("peglib: maximum rule nesting exceeded at byte %d", e.Offset)
+}
+
+// StepError is returned by ParseContext if rules are invoked more often than
+// Limits.MaxSteps.
+type StepError struct {
+	Offset int // of the input of the last rule invocation
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("peglib: maximum number of steps exceeded at byte %d", e.Offset)
+}
+
+// OutputError is returned by ParseContext if the output grows beyond
+// Limits.MaxOutput.
+type OutputError struct {
+	Offset int // of the input of the last rule invocation
+}
+
+func (e *OutputError) Error() string {
+	return fmt.Sprintf("peglib: maximum output size exceeded at byte %d", e.Offset)
+}
+
+// CanceledError is returned by ParseContext if its context is done before
+// parsing ends.
+type CanceledError struct {
+	Offset int   // of the input of the last rule invocation
+	Err    error // of the context
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("peglib: parsing canceled at byte %d: %v", e.Offset, e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+const noLimit = int(^uint(0) >> 1)
+
+// cancelInterval is the number of steps between checks of the context.
+const cancelInterval = 1024
+
+var (
+	depth, steps                  int
+	maxDepth, maxSteps, maxOutput = noLimit, noLimit, noLimit
+	cstNodes                      int // built during the parse, see Limits.MaxOutput
+	limitContext                  context.Context
+	limitInputLength, limitOffset int // of the whole input and of the last rule invocation
+	limitFarthest                 int // offset of the farthest failure of a terminal, see Fail
+)
+
+// ParseContext calls rule on input, which must not contain the terminating
+// zero byte, and returns the output of rule. If the rule does not match, the
+// Position of the returned ParsingError is the farthest byte at which a
+// terminal failed. If the rule matches only a prefix of the input, the
+// Position is the end of the match or the farthest failed terminal, whichever
+// is later.
+//
+// The parse ends with a DepthError, StepError or OutputError if it exceeds
+// limits, and with a CanceledError once ctx is done.
+//
+// The rule must be generated with the limits option, otherwise only the output
+// size is limited: the depth and the number of steps are not bounded, so a
+// deeply nested input may overflow the stack; ctx is only checked before the
+// parse starts, so a running parse is not canceled; and a rule that does not
+// match is reported at byte 0.
+func ParseContext(ctx context.Context, rule func([]byte) []byte, input []byte, limits Limits) (interface{}, error) {
+	var b ValueBuilder
+	if err := ParseContextBuilder(ctx, rule, input, limits, &b); err != nil {
+		return nil, err
+	}
+	return b.Result(), nil
+}
+
+// ParseContextBuilder is like ParseContext, but delivers the output to b, see
+// Build.
+func ParseContextBuilder(ctx context.Context, rule func([]byte) []byte, input []byte, limits Limits, b OutputBuilder) (err error) {
+	buf := append(input[:len(input):len(input)], 0)
+	Reset()
+	maxDepth, maxSteps, maxOutput = limitOrNone(limits.MaxDepth), limitOrNone(limits.MaxSteps), limitOrNone(limits.MaxOutput)
+	if ctx.Done() != nil {
+		limitContext = ctx
+	}
+	limitInputLength = len(buf)
+	defer func() {
+		maxDepth, maxSteps, maxOutput = noLimit, noLimit, noLimit
+		limitContext = nil
+		Reset()
+		switch e := recover().(type) {
+		case nil:
+		case *DepthError, *StepError, *OutputError, *CanceledError:
+			err = e.(error)
+		default:
+			panic(e)
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return &CanceledError{Err: err}
+	}
+	rest := rule(buf)
+	if limits.Strict && steps == 0 {
+		return ErrNoLimits
+	}
+	if rest == nil {
+		return &ParsingError{Input: input, Position: limitFarthest, OtherReasons: []string{"no match"}}
+	}
+	if len(rest) != 1 {
+		// a failed terminal beyond the end of the match is a better hint
+		pos := len(buf) - len(rest)
+		if limitFarthest > pos {
+			pos = limitFarthest
+		}
+		return &ParsingError{Input: input, Position: pos, OtherReasons: []string{"unexpected input"}}
+	}
+	Build(b)
+	return nil
+}
+
+func limitOrNone(limit int) int {
+	if limit <= 0 {
+		return noLimit
+	}
+	return limit
+}
+
+// Enter starts a rule invocation at input and checks the limits.
+func Enter(input []byte) {
+	depth++
+	steps++
+	limitOffset = limitInputLength - len(input)
+	if depth > maxDepth {
+		panic(&DepthError{Offset: limitOffset})
+	}
+	if steps > maxSteps {
+		panic(&StepError{Offset: limitOffset})
+	}
+	if steps%cancelInterval == 0 && limitContext != nil {
+		select {
+		case <-limitContext.Done():
+			panic(&CanceledError{Offset: limitOffset, Err: limitContext.Err()})
+		default:
+		}
+	}
+}
+
+// Leave ends a rule invocation and returns rest.
+func Leave(rest []byte) []byte {
+	depth--
+	return rest
+}
+
+// Fail records that a terminal failed at the byte n of input. The farthest
+// failure is the position of the ParsingError returned by ParseContext if the
+// rule does not match.
+func Fail(input []byte, n int) {
+	if offset := limitInputLength - len(input) + n; offset > limitFarthest {
+		limitFarthest = offset
+	}
+}
+
+// FailString records the failure of a string terminal at the first byte of
+// input not matching s, see Fail.
+func FailString(input []byte, s string) {
+	n := 0
+	for n < len(s) && n < len(input) && input[n] == s[n] {
+		n++
+	}
+	Fail(input, n)
+}
+
+// FailStringFold is like FailString for a case-insensitive string terminal.
+func FailStringFold(input []byte, s string) {
+	n := 0
+	for n < len(s) && n < len(input) && bytes.EqualFold(input[n:n+1], []byte(s[n:n+1])) {
+		n++
+	}
+	Fail(input, n)
+}
+
+// checkOutput panics if the output would exceed Limits.MaxOutput with n more
+// nodes.
+func checkOutput(n int) {
+	if len(nodes)+cstNodes+n > maxOutput {
+		panic(&OutputError{Offset: limitOffset})
+	}
+}
@@ -0,0 +1,222 @@
+package peglib
+
+// Edit replaces Deleted bytes at Offset by Inserted.
+type Edit struct {
+	Offset   int
+	Deleted  int
+	Inserted []byte
+}
+
+// Document is an input parsed by a rule of a parser generated with
+// memoization. The rules remember their results at each position, so that
+// after edits Reparse only runs the rules whose results may have changed.
+// A result is reused if the rule did not examine any edited byte.
+type Document struct {
+	rule   func([]byte) []byte
+	input  []byte        // with the terminating zero byte
+	memo   [][]memoEntry // by position
+	result interface{}
+	length int
+	ok     bool
+	hits   int
+}
+
+type memoEntry struct {
+	rule     int
+	length   int // of the match, or -1 if the rule failed
+	examined int // number of bytes from the position the result depends on
+	node     *Node
+}
+
+// MemoState is kept by a memoized rule while it runs.
+type MemoState struct {
+	examined int
+	mark     int
+}
+
+// memoDocument is the document being parsed, if any.
+var memoDocument *Document
+
+// examined is the length of the shortest remaining input examined by the
+// running rule, i.e. it marks the farthest examined byte.
+var examined int
+
+// ParseDocument parses input with rule, remembering the results of all rule
+// invocations. The input must not contain the terminating zero byte.
+func ParseDocument(rule func([]byte) []byte, input []byte) *Document {
+	d := &Document{rule: rule, input: append(input[:len(input):len(input)], 0)}
+	d.memo = make([][]memoEntry, len(d.input))
+	d.parse()
+	return d
+}
+
+// Reparse applies the edits in order and parses the document again. The
+// offset of an edit refers to the input after the preceding edits. Results
+// of rules that examined edited bytes are discarded, results after the edits
+// are moved along with the input.
+func (d *Document) Reparse(edits ...Edit) {
+	for _, e := range edits {
+		d.apply(e)
+	}
+	d.parse()
+}
+
+// Input returns the current input.
+func (d *Document) Input() []byte {
+	return d.input[:len(d.input)-1]
+}
+
+// Result returns the output of the last parse, i.e. the concrete syntax tree
+// for parsers generated with the CST output mode, or nil if parsing failed.
+func (d *Document) Result() interface{} {
+	return d.result
+}
+
+// Match returns the length of the input matched by the last parse and
+// whether it succeeded.
+func (d *Document) Match() (int, bool) {
+	return d.length, d.ok
+}
+
+// Hits returns the number of rule invocations of the last parse that were
+// answered from the memoized results.
+func (d *Document) Hits() int {
+	return d.hits
+}
+
+func (d *Document) parse() {
+	Reset()
+	memoDocument = d
+	examined = len(d.input)
+	d.hits = 0
+	rest := d.rule(d.input)
+	memoDocument = nil
+	d.result, d.length, d.ok = nil, 0, rest != nil
+	if d.ok {
+		d.length = len(d.input) - len(rest)
+		d.result = Top()
+	}
+	Reset()
+}
+
+func (d *Document) apply(e Edit) {
+	end := e.Offset + e.Deleted
+	if e.Offset < 0 || e.Deleted < 0 || end > len(d.input)-1 {
+		panic("peglib: edit out of range")
+	}
+
+	input := make([]byte, 0, len(d.input)-e.Deleted+len(e.Inserted))
+	input = append(input, d.input[:e.Offset]...)
+	input = append(input, e.Inserted...)
+	input = append(input, d.input[end:]...)
+
+	memo := make([][]memoEntry, 0, len(input))
+	for pos, entries := range d.memo[:e.Offset] {
+		kept := entries[:0]
+		for _, m := range entries {
+			if pos+m.examined <= e.Offset {
+				kept = append(kept, m)
+			}
+		}
+		memo = append(memo, kept)
+	}
+	memo = append(memo, make([][]memoEntry, len(e.Inserted))...)
+	memo = append(memo, d.memo[end:]...)
+
+	// move the remembered nodes to the new input, so that they do not keep
+	// the old one alive
+	for _, entries := range memo {
+		for _, m := range entries {
+			if m.node != nil {
+				rebase(m.node, d.input, input, end, len(e.Inserted)-e.Deleted)
+			}
+		}
+	}
+
+	d.input, d.memo = input, memo
+}
+
+// rebase changes the texts of the tree of n from old to input, in which the
+// bytes from end on have moved by delta. Nodes already referring to input are
+// skipped along with their children, which have been rebased with them.
+func rebase(n *Node, old, input []byte, end, delta int) {
+	offset := cap(old) - cap(n.Text)
+	if offset < 0 || offset >= len(old) || &old[offset] != &n.Text[:1][0] {
+		return
+	}
+	if offset >= end {
+		offset += delta
+	}
+	n.Text = InputRange(input[offset : offset+len(n.Text)])
+	for _, c := range n.Children {
+		rebase(c, old, input, end, delta)
+	}
+}
+
+// Examine records that n bytes of input have been examined.
+func Examine(input []byte, n int) {
+	if r := len(input) - n; r < examined {
+		examined = r
+	}
+}
+
+// MemoLookup returns the remembered result of rule at input. If the rule
+// matched, its node is pushed when building a concrete syntax tree. The bool
+// is false if there is no result.
+func MemoLookup(rule int, input []byte) ([]byte, bool) {
+	d := memoDocument
+	if d == nil {
+		return nil, false
+	}
+	for _, m := range d.memo[len(d.input)-len(input)] {
+		if m.rule != rule {
+			continue
+		}
+		d.hits++
+		Examine(input, m.examined)
+		if m.length < 0 {
+			return nil, true
+		}
+		if m.node != nil {
+			pushNodeValue(m.node, input[:m.length])
+		}
+		return input[m.length:], true
+	}
+	return nil, false
+}
+
+// MemoEnter starts tracking the input examined by a rule at input.
+func MemoEnter(input []byte) MemoState {
+	s := MemoState{examined: examined, mark: len(outputStack)}
+	examined = len(input)
+	return s
+}
+
+// MemoFailure remembers that rule failed at start and returns nil.
+func MemoFailure(rule int, s MemoState, start []byte) []byte {
+	memoize(rule, s, start, -1)
+	return nil
+}
+
+// MemoSuccess remembers that rule matched from start to end and returns end.
+func MemoSuccess(rule int, s MemoState, start, end []byte) []byte {
+	memoize(rule, s, start, len(start)-len(end))
+	return end
+}
+
+func memoize(rule int, s MemoState, start []byte, length int) {
+	if d := memoDocument; d != nil {
+		m := memoEntry{rule: rule, length: length, examined: len(start) - examined}
+		if m.examined < length {
+			m.examined = length
+		}
+		if length >= 0 && len(outputStack) > s.mark {
+			m.node, _ = nodes[outputStack[len(outputStack)-1].root].value.(*Node)
+		}
+		pos := len(d.input) - len(start)
+		d.memo[pos] = append(d.memo[pos], m)
+	}
+	if s.examined < examined {
+		examined = s.examined
+	}
+}
@@ -0,0 +1,360 @@
+package peglib
+
+// OutputBuilder receives the output of a parse as nested events. A value is
+// either an object, an array, a range of the input or another Go value, e.g. a
+// boolean. Objects contain labels, each of which contains a single value.
+//
+// Since a value may still be discarded by backtracking while parsing, the
+// events are only delivered by Build, after the parse.
+type OutputBuilder interface {
+	// BeginObject starts an object. The class is the name given by an object
+	// creator, or empty for objects made of labels only.
+	BeginObject(class string)
+	EndObject()
+
+	BeginLabel(name string)
+	EndLabel()
+
+	BeginArray()
+	EndArray()
+
+	// Range reports a value made of the matched input.
+	Range(r InputRange)
+
+	// Value reports any other value, e.g. a boolean.
+	Value(v interface{})
+}
+
+// Build delivers the value on top of the output stack to b. It does nothing
+// if there is no output.
+func Build(b OutputBuilder) {
+	if len(outputStack) == 0 {
+		return
+	}
+	buildNode(b, outputStack[len(outputStack)-1].root)
+}
+
+// Top returns the value on top of the output stack as built by ValueBuilder,
+// or nil if there is no output.
+// Generated code uses it to make labelled values available to Go code
+// embedded in the grammar. The value of each node is built once and kept for
+// later calls and as part of enclosing values, so calling it for each label of
+// a nested value does not build the inner values again. The values must thus
+// not be modified.
+func Top() interface{} {
+	if len(outputStack) == 0 {
+		return nil
+	}
+	return nodeValue(outputStack[len(outputStack)-1].root)
+}
+
+// OutputCount returns the number of values on the output stack. After a
+// successful parse, there is at most one.
+func OutputCount() int {
+	return len(outputStack)
+}
+
+// outputValues returns all values on the output stack as returned by Top,
+// from the bottom to the top.
+func outputValues() []interface{} {
+	values := make([]interface{}, len(outputStack))
+	for i, e := range outputStack {
+		values[i] = nodeValue(e.root)
+	}
+	return values
+}
+
+// nodeValue returns the value of node i as returned by Top, building it with
+// a ValueBuilder that reuses the values kept for the child nodes.
+func nodeValue(i int) interface{} {
+	if !nodes[i].isBuilt {
+		b := ValueBuilder{reuse: true}
+		buildNode(&b, i)
+		nodes[i].built, nodes[i].isBuilt = b.Result(), true
+	}
+	return nodes[i].built
+}
+
+func buildNode(b OutputBuilder, i int) {
+	n := &nodes[i]
+	switch n.kind {
+	case rangeNode:
+		b.Range(n.text)
+	case valueNode:
+		b.Value(n.value)
+	case labelNode:
+		// a label on its own is an object with a single label
+		b.BeginObject("")
+		buildLabel(b, n)
+		b.EndObject()
+	case arrayNode:
+		b.BeginArray()
+		for c := n.first; c != -1; c = nodes[c].next {
+			buildChild(b, c)
+		}
+		b.EndArray()
+	case objectNode:
+		b.BeginObject(n.name)
+		buildLabels(b, n)
+		b.EndObject()
+	}
+}
+
+func buildLabel(b OutputBuilder, n *node) {
+	b.BeginLabel(n.name)
+	buildChild(b, n.first)
+	b.EndLabel()
+}
+
+// buildChild delivers the value of a child node. The ValueBuilder of Top adds
+// the value kept for the node instead.
+func buildChild(b OutputBuilder, i int) {
+	if v, ok := b.(*ValueBuilder); ok && v.reuse {
+		v.add(nodeValue(i))
+		return
+	}
+	buildNode(b, i)
+}
+
+// buildLabels delivers the labels of an object. The labels of nested objects
+// without class belong to the enclosing object, other values are dropped.
+func buildLabels(b OutputBuilder, n *node) {
+	for c := n.first; c != -1; c = nodes[c].next {
+		switch child := &nodes[c]; {
+		case child.kind == labelNode:
+			buildLabel(b, child)
+		case child.kind == objectNode && child.name == "":
+			buildLabels(b, child)
+		}
+	}
+}
+
+// ValueBuilder is the default OutputBuilder. It builds maps for objects,
+// slices of type []interface{} for arrays and InputRange values for ranges.
+// Objects with a class are passed to Factory, or to the package variable
+// Factory if it is nil. If a label occurs more than once in an object, the
+// first one is used.
+type ValueBuilder struct {
+	Factory func(class string, value interface{}) interface{}
+
+	stack  []valueFrame
+	result interface{}
+	reuse  bool // take the values of child nodes from nodeValue, see Top
+}
+
+type valueFrame struct {
+	class  string
+	label  string
+	object map[string]interface{}
+	array  []interface{}
+	value  interface{}
+	kind   nodeKind
+}
+
+// Result returns the value built last.
+func (b *ValueBuilder) Result() interface{} {
+	return b.result
+}
+
+func (b *ValueBuilder) BeginObject(class string) {
+	b.stack = append(b.stack, valueFrame{kind: objectNode, class: class, object: make(map[string]interface{})})
+}
+
+func (b *ValueBuilder) EndObject() {
+	f := b.pop()
+	if f.class != "" {
+		factory := b.Factory
+		if factory == nil {
+			factory = Factory
+		}
+		b.add(factory(f.class, f.object))
+		return
+	}
+	b.add(f.object)
+}
+
+func (b *ValueBuilder) BeginLabel(name string) {
+	b.stack = append(b.stack, valueFrame{kind: labelNode, label: name})
+}
+
+func (b *ValueBuilder) EndLabel() {
+	f := b.pop()
+	object := b.stack[len(b.stack)-1].object
+	if _, ok := object[f.label]; !ok {
+		object[f.label] = f.value
+	}
+}
+
+func (b *ValueBuilder) BeginArray() {
+	b.stack = append(b.stack, valueFrame{kind: arrayNode, array: []interface{}{}})
+}
+
+func (b *ValueBuilder) EndArray() {
+	b.add(b.pop().array)
+}
+
+func (b *ValueBuilder) Range(r InputRange) {
+	b.add(r)
+}
+
+func (b *ValueBuilder) Value(v interface{}) {
+	b.add(v)
+}
+
+func (b *ValueBuilder) pop() valueFrame {
+	f := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+	return f
+}
+
+func (b *ValueBuilder) add(v interface{}) {
+	if len(b.stack) == 0 {
+		b.result = v
+		return
+	}
+	f := &b.stack[len(b.stack)-1]
+	switch f.kind {
+	case arrayNode:
+		f.array = append(f.array, v)
+	case labelNode:
+		f.value = v
+	}
+}
+
+type nodeKind uint8
+
+const (
+	rangeNode nodeKind = iota
+	valueNode
+	labelNode
+	arrayNode
+	objectNode
+)
+
+// node is an element of the tree built while parsing. Children are linked by
+// their indexes in nodes; -1 marks the end of a list.
+type node struct {
+	kind  nodeKind
+	name  string      // of a label, or the class of an object
+	text  InputRange  // of a range
+	value interface{} // of another value
+	first int
+	last  int
+	next  int
+
+	built   interface{} // value returned by Top, if isBuilt
+	isBuilt bool
+}
+
+// stackEntry is a value on the output stack. All nodes from start on belong to
+// this value or to the values above it, so popping it truncates nodes.
+type stackEntry struct {
+	start int
+	root  int
+}
+
+// nodes holds the nodes of all values on the output stack. Its memory is kept
+// across parses.
+var nodes []node
+
+func newNode(kind nodeKind) int {
+	checkOutput(1)
+	nodes = append(nodes, node{kind: kind, first: -1, last: -1, next: -1})
+	return len(nodes) - 1
+}
+
+// pushNode pushes a value consisting of a single new node.
+func pushNode(kind nodeKind) *node {
+	start := len(nodes)
+	i := newNode(kind)
+	outputStack = append(outputStack, stackEntry{start: start, root: i})
+	return &nodes[i]
+}
+
+// combine replaces the count values on top of the output stack by a new node
+// having them as children.
+func combine(kind nodeKind, count int) *node {
+	entries := outputStack[len(outputStack)-count:]
+	start := len(nodes)
+	if count != 0 {
+		start = entries[0].start
+	}
+	i := newNode(kind)
+	for _, e := range entries {
+		appendChild(i, e.root)
+	}
+	outputStack = append(outputStack[:len(outputStack)-count], stackEntry{start: start, root: i})
+	return &nodes[i]
+}
+
+func appendChild(parent, child int) {
+	p := &nodes[parent]
+	p.built, p.isBuilt = nil, false
+	if p.first == -1 {
+		p.first = child
+	} else {
+		nodes[p.last].next = child
+	}
+	p.last = child
+}
+
+// popOutput removes the count values on top of the output stack together with
+// their nodes.
+func popOutput(count int) {
+	if count == 0 {
+		return
+	}
+	start := outputStack[len(outputStack)-count].start
+	clearNodes(start)
+	outputStack = outputStack[:len(outputStack)-count]
+}
+
+// clearNodes truncates nodes, releasing the memory referenced by the removed
+// ones.
+func clearNodes(start int) {
+	for i := start; i < len(nodes); i++ {
+		nodes[i] = node{}
+	}
+	nodes = nodes[:start]
+}
+
+// findLabel returns the label name of the object or label i as delivered by
+// Build, or -1 if there is none.
+func findLabel(i int, name string) int {
+	n := &nodes[i]
+	switch {
+	case n.kind == labelNode && n.name == name:
+		return i
+	case n.kind == objectNode && n.name == "":
+		for c := n.first; c != -1; c = nodes[c].next {
+			if l := findLabel(c, name); l != -1 {
+				return l
+			}
+		}
+	}
+	return -1
+}
+
+// copyNode appends a copy of the tree of node i to nodes and returns its
+// index.
+func copyNode(i int) int {
+	c := newNode(nodes[i].kind)
+	nodes[c].name, nodes[c].text, nodes[c].value = nodes[i].name, nodes[i].text, nodes[i].value
+	for child := nodes[i].first; child != -1; child = nodes[child].next {
+		appendChild(c, copyNode(child))
+	}
+	return c
+}
+
+// popValue removes the value on top of the output stack and returns it as
+// built by ValueBuilder.
+func popValue() interface{} {
+	v := Top()
+	popOutput(1)
+	return v
+}
+
+// pushValue pushes a Go value.
+func pushValue(v interface{}) {
+	pushNode(valueNode).value = v
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 )
 
 type Stringer interface {
@@ -54,12 +55,27 @@ func (e *ParsingError) Error() string {
 	return fmt.Sprintf("at line %d, column %d (byte %d, after %q): %s", line, column, e.Position, string(before[prefixOffset:]), strings.Join(reasons, " / "))
 }
 
+// State is implemented by user-defined parser state, e.g. a symbol table that
+// actions populate and predicates consult. Generated code takes a snapshot
+// wherever it may backtrack and restores it when it does, so changes made by
+// a failed alternative are undone.
+type State interface {
+	Snapshot() interface{}
+	Restore(snapshot interface{})
+}
+
+// ParseMutex serializes the parses of a program that parses in several
+// goroutines, as peglib keeps the state of a parse in package variables.
+var ParseMutex sync.Mutex
+
+var UserState State
 var Debug = false
 var Factory = func(class string, value interface{}) interface{} { return value }
 var inputOffset uintptr
-var outputStack []interface{}
+var outputStack []stackEntry
 var localsStack []interface{}
-var tempSource map[string]interface{}
+var indentation *indentLevel
+var source int // index in outputStack, see SetAsSource
 var failurePosition int
 var failureExpectations []string
 var failureOtherReasons []string
@@ -76,11 +92,79 @@ func Test(rule func([]byte) []byte) {
 	if len(outputStack) != 1 {
 		panic("len(outputStack) != 1")
 	}
-	if err := json.NewEncoder(os.Stdout).Encode(outputStack[0]); err != nil {
+	if err := json.NewEncoder(os.Stdout).Encode(Top()); err != nil {
 		panic(err)
 	}
 }
 
+// Reset clears the output stack and the parser state, so that a rule can be
+// called again after a previous parse. The stacks keep their memory, so that
+// later parses do not need to grow them again. Rule invocations left running
+// by an abandoned parse are ended for Trace.
+func Reset() {
+	clearNodes(0)
+	outputStack = outputStack[:0]
+	localsStack = localsStack[:0]
+	indentation = nil
+	failurePosition = 0
+	failureExpectations = nil
+	failureOtherReasons = nil
+	depth, steps, cstNodes = 0, 0, 0
+	limitOffset, limitFarthest = 0, 0
+	abortTrace()
+}
+
+var recognizeBuffer []byte
+
+// Recognize calls rule on input, which must not contain the terminating zero
+// byte, and returns the length of the matched prefix and whether rule matched
+// at all. Any output is discarded. If the byte after input is already a zero
+// byte, the input is used in place, otherwise it is copied into a buffer that
+// is reused by later calls. With a parser generated to only recognize input,
+// matching thus does not allocate.
+func Recognize(rule func([]byte) []byte, input []byte) (int, bool) {
+	var buf []byte
+	if cap(input) > len(input) && input[:len(input)+1][len(input)] == 0 {
+		buf = input[:len(input)+1]
+	} else {
+		recognizeBuffer = append(append(recognizeBuffer[:0], input...), 0)
+		buf = recognizeBuffer
+	}
+	Reset()
+	rest := rule(buf)
+	Reset()
+	if rest == nil {
+		return 0, false
+	}
+	return len(buf) - len(rest), true
+}
+
+// ParsePrefix calls rule on input, which must not contain the terminating zero
+// byte, and returns the output of rule for the matched prefix, the input
+// following it and its offset in input. Unlike Test, rule does not need to
+// match all of the input. The bool is false if rule did not match at all.
+//
+// If the byte after input is already a zero byte, the input is used in place,
+// otherwise it is copied. The returned rest is always followed by a zero byte,
+// so that parsing consecutive prefixes by passing it to the next call copies
+// the input at most once. Ranges within the output refer to the input used.
+func ParsePrefix(rule func([]byte) []byte, input []byte) (interface{}, []byte, int, bool) {
+	var buf []byte
+	if cap(input) > len(input) && input[:len(input)+1][len(input)] == 0 {
+		buf = input[:len(input)+1]
+	} else {
+		buf = append(input[:len(input):len(input)], 0)
+	}
+	Reset()
+	defer Reset()
+	rest := rule(buf)
+	if len(rest) == 0 {
+		// no match, or the terminating zero byte was consumed
+		return nil, input, 0, false
+	}
+	return Top(), rest[:len(rest)-1], len(buf) - len(rest), true
+}
+
 func HasPrefix(input []byte, prefix string) bool {
 	return len(input) >= len(prefix) && bytes.Equal(input[:len(prefix)], []byte(prefix))
 }
@@ -93,109 +177,140 @@ func ContainsByte(s string, b byte) bool {
 	return strings.IndexByte(s, b) != -1
 }
 
-func pushOutput(v interface{}) {
-	outputStack = append(outputStack, v)
-}
+// ByteSet is a bitmap of 256 bytes, used by generated code for character
+// classes that are not simple ranges.
+type ByteSet [4]uint64
 
-func popOutput() interface{} {
-	v := outputStack[len(outputStack)-1]
-	outputStack = outputStack[:len(outputStack)-1]
-	return v
+func (s *ByteSet) Contains(b byte) bool {
+	return s[b>>6]&(1<<(b&63)) != 0
 }
 
 func PushEmpty() {
 	if Debug {
 		fmt.Printf("PushEmpty()\n")
 	}
-	pushOutput(make(map[string]interface{}))
+	pushNode(objectNode)
 }
 
 func PushInputRange(startInput, endInput []byte) {
 	if Debug {
 		fmt.Printf("PushInputRange(...)\n")
 	}
-	pushOutput(InputRange(startInput[:len(startInput)-len(endInput)]))
+	pushNode(rangeNode).text = InputRange(startInput[:len(startInput)-len(endInput)])
 }
 
 func PushTrue() {
 	if Debug {
 		fmt.Printf("PushTrue()\n")
 	}
-	pushOutput(true)
+	pushValue(true)
 }
 
 func PushFalse() {
 	if Debug {
 		fmt.Printf("PushFalse()\n")
 	}
-	pushOutput(false)
+	pushValue(false)
 }
 
 func PushString(value string) {
 	if Debug {
 		fmt.Printf("PushString(%q)\n", value)
 	}
-	pushOutput(StringData(value))
+	pushValue(StringData(value))
 }
 
 func PushArray() {
 	if Debug {
 		fmt.Printf("PushArray()\n")
 	}
-	pushOutput([]interface{}{})
+	pushNode(arrayNode)
 }
 
 func AppendToArray() {
 	if Debug {
 		fmt.Printf("AppendToArray()\n")
 	}
-	v := popOutput()
-	pushOutput(append(popOutput().([]interface{}), v))
+	v := outputStack[len(outputStack)-1]
+	outputStack = outputStack[:len(outputStack)-1]
+	appendChild(outputStack[len(outputStack)-1].root, v.root)
 }
 
 func MakeLabel(name string) {
 	if Debug {
 		fmt.Printf("MakeLabel(%q)\n", name)
 	}
-	pushOutput(map[string]interface{}{name: popOutput()})
+	combine(labelNode, 1).name = name
 }
 
 func MergeLabels(count int) {
 	if Debug {
 		fmt.Printf("MergeLabels(%d)\n", count)
 	}
-	merged := make(map[string]interface{})
-	for i := 0; i < count; i++ {
-		if m, ok := popOutput().(map[string]interface{}); ok {
-			for k, v := range m {
-				merged[k] = v
-			}
-		}
+	combine(objectNode, count)
+}
+
+// MakeArray replaces the count values on top of the output stack by an array
+// containing them.
+func MakeArray(count int) {
+	if Debug {
+		fmt.Printf("MakeArray(%d)\n", count)
 	}
-	pushOutput(merged)
+	combine(arrayNode, count)
 }
 
-func MakeValue(code string, filename string, line int) {
+// makeOperator replaces the values on top of the output stack by an object
+// with a label of the given name for each of them.
+func makeOperator(names ...string) {
+	for i, name := range names {
+		e := &outputStack[len(outputStack)-len(names)+i]
+		l := newNode(labelNode)
+		nodes[l].name = name
+		appendChild(l, e.root)
+		e.root = l
+	}
+	combine(objectNode, len(names))
+}
+
+// MakeInfix replaces the left operand, operator and right operand on top of
+// the output stack by a map with the keys "l", "op" and "r".
+func MakeInfix() {
+	if Debug {
+		fmt.Printf("MakeInfix()\n")
+	}
+	makeOperator("l", "op", "r")
+}
+
+// MakePrefix replaces the operator and operand on top of the output stack by
+// a map with the keys "op" and "r".
+func MakePrefix() {
 	if Debug {
-		fmt.Printf("MakeValue(%q, %q, %d)\n", code, filename, line)
+		fmt.Printf("MakePrefix()\n")
 	}
-	panic("makeValue not supported")
+	makeOperator("op", "r")
+}
+
+// MakePostfix replaces the operand and operator on top of the output stack by
+// a map with the keys "l" and "op".
+func MakePostfix() {
+	if Debug {
+		fmt.Printf("MakePostfix()\n")
+	}
+	makeOperator("l", "op")
 }
 
 func MakeObject(class string) {
 	if Debug {
 		fmt.Printf("MakeObject(%q)\n", class)
 	}
-	pushOutput(Factory(class, popOutput()))
+	combine(objectNode, 1).name = class
 }
 
 func Pop(count int) {
 	if Debug {
 		fmt.Printf("Pop(%d)\n", count)
 	}
-	for i := 0; i < count; i++ {
-		popOutput()
-	}
+	popOutput(count)
 }
 
 func LocalsPush(count int) {
@@ -203,7 +318,7 @@ func LocalsPush(count int) {
 		fmt.Printf("LocalsPush(%d)\n", count)
 	}
 	for i := 0; i < count; i++ {
-		localsStack = append(localsStack, popOutput())
+		localsStack = append(localsStack, popValue())
 	}
 }
 
@@ -211,7 +326,7 @@ func LocalsLoad(index int) {
 	if Debug {
 		fmt.Printf("LocalsLoad(%d)\n", index)
 	}
-	pushOutput(localsStack[len(localsStack)-1-int(index)])
+	pushValue(localsStack[len(localsStack)-1-int(index)])
 }
 
 func LocalsPop(count int) {
@@ -241,29 +356,127 @@ func LocalsPop(count int) {
 // 	return 0
 // }
 
+// SetAsSource makes the value on top of the output stack the source of the
+// labels read by ReadFromSource, while the data of an object creator is built
+// above it.
 func SetAsSource() {
 	if Debug {
 		fmt.Printf("SetAsSource()\n")
 	}
-	tempSource, _ = popOutput().(map[string]interface{})
+	source = len(outputStack) - 1
 }
 
+// ReadFromSource pushes a copy of the value of the label name of the source,
+// or nil if the source has no such label.
 func ReadFromSource(name string) {
 	if Debug {
 		fmt.Printf("ReadFromSource(%q)\n", name)
 	}
-	pushOutput(tempSource[name])
+	start := len(nodes)
+	var root int
+	if l := findLabel(outputStack[source].root, name); l != -1 {
+		root = copyNode(nodes[l].first)
+	} else {
+		root = newNode(valueNode)
+	}
+	outputStack = append(outputStack, stackEntry{start: start, root: root})
+}
+
+// ReplaceSource replaces the source and the value above it by that value.
+func ReplaceSource() {
+	if Debug {
+		fmt.Printf("ReplaceSource()\n")
+	}
+	top := outputStack[len(outputStack)-1]
+	top.start = outputStack[source].start
+	outputStack = append(outputStack[:source], top)
+}
+
+type stateSnapshot struct {
+	indentation *indentLevel
+	user        interface{}
+}
+
+func SaveState() interface{} {
+	if Debug {
+		fmt.Printf("SaveState()\n")
+	}
+	snapshot := stateSnapshot{indentation: indentation}
+	if UserState != nil {
+		snapshot.user = UserState.Snapshot()
+	}
+	return snapshot
+}
+
+func RestoreState(snapshot interface{}) {
+	if Debug {
+		fmt.Printf("RestoreState(...)\n")
+	}
+	s := snapshot.(stateSnapshot)
+	indentation = s.indentation
+	if UserState != nil {
+		UserState.Restore(s.user)
+	}
 }
 
-func TraceEnter(name string) {
+// indentLevel is an entry of the indentation stack. Entries are never
+// modified, so a pointer to the innermost entry is a snapshot of the stack.
+type indentLevel struct {
+	width  int
+	parent *indentLevel
+}
+
+func (l *indentLevel) Width() int {
+	if l == nil {
+		return 0
+	}
+	return l.width
+}
+
+func indentWidth(input []byte) int {
+	n := 0
+	for n < len(input) && (input[n] == ' ' || input[n] == '\t') {
+		n++
+	}
+	// the byte ending the indentation has been examined as well
+	Examine(input, n+1)
+	return n
+}
+
+// Indent succeeds if the line at input is indented deeper than the innermost
+// block and opens a new block with its indentation. It consumes no input.
+func Indent(input []byte) bool {
 	if Debug {
-		fmt.Printf("TraceEnter(%q)\n", name)
+		fmt.Printf("Indent(...)\n")
 	}
+	width := indentWidth(input)
+	if width <= indentation.Width() {
+		return false
+	}
+	indentation = &indentLevel{width: width, parent: indentation}
+	return true
 }
 
-func TraceLeave(name string, successful bool) {
+// Samedent succeeds if the line at input is indented exactly like the
+// innermost block and consumes the indentation. It returns nil on failure.
+func Samedent(input []byte) []byte {
 	if Debug {
-		fmt.Printf("TraceLeave(%q, %t)\n", name, successful)
+		fmt.Printf("Samedent(...)\n")
+	}
+	width := indentWidth(input)
+	if width != indentation.Width() {
+		return nil
+	}
+	return input[width:]
+}
+
+// Dedent closes the innermost block.
+func Dedent() {
+	if Debug {
+		fmt.Printf("Dedent()\n")
+	}
+	if indentation != nil {
+		indentation = indentation.parent
 	}
 }
 
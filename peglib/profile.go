@@ -0,0 +1,303 @@
+package peglib
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Profiler is a Tracer collecting statistics about the rules of parsers
+// generated with the trace option. Install it as Trace before parsing.
+type Profiler struct {
+	rules    map[string]*RuleProfile
+	active   map[string]int // invocations of each rule on the stack
+	stack    []profileFrame
+	entries  map[ruleAt]int
+	samples  map[string]*profileSample // by call stack
+	start    time.Time
+	duration time.Duration
+}
+
+// RuleProfile holds the statistics of a rule.
+type RuleProfile struct {
+	Rule        string
+	Invocations int
+	Successes   int
+	Failures    int
+	Consumed    int // bytes matched by successful invocations
+
+	// Inclusive is the time spent in the rule including the rules it called,
+	// Exclusive the time spent in the rule only. The inclusive time of
+	// recursive invocations is counted once.
+	Inclusive time.Duration
+	Exclusive time.Duration
+}
+
+// HotSpot is a position where a rule was invoked more than once, i.e. where
+// backtracking parsed the same input with the same rule again.
+type HotSpot struct {
+	Rule        string
+	Pos         int
+	Invocations int
+}
+
+type ruleAt struct {
+	rule string
+	pos  int
+}
+
+type profileFrame struct {
+	rule     string
+	start    time.Time
+	children time.Duration
+}
+
+// profileSample aggregates the invocations with the same call stack.
+type profileSample struct {
+	stack       []string // outermost rule first
+	invocations int64
+	exclusive   time.Duration
+}
+
+// NewProfiler returns an empty Profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		rules:   make(map[string]*RuleProfile),
+		active:  make(map[string]int),
+		entries: make(map[ruleAt]int),
+		samples: make(map[string]*profileSample),
+	}
+}
+
+func (p *Profiler) Enter(rule string, pos int) {
+	now := time.Now()
+	if len(p.stack) == 0 && p.start.IsZero() {
+		p.start = now
+	}
+	p.entries[ruleAt{rule, pos}]++
+	p.active[rule]++
+	p.stack = append(p.stack, profileFrame{rule: rule, start: now})
+}
+
+func (p *Profiler) Leave(rule string, pos, end int, ok bool) {
+	now := time.Now()
+	f := p.stack[len(p.stack)-1]
+	elapsed := now.Sub(f.start)
+	exclusive := elapsed - f.children
+
+	r := p.rules[rule]
+	if r == nil {
+		r = &RuleProfile{Rule: rule}
+		p.rules[rule] = r
+	}
+	r.Invocations++
+	if ok {
+		r.Successes++
+		r.Consumed += end - pos
+	} else {
+		r.Failures++
+	}
+	r.Exclusive += exclusive
+	p.active[rule]--
+	if p.active[rule] == 0 {
+		r.Inclusive += elapsed
+	}
+
+	names := make([]string, len(p.stack))
+	for i, f := range p.stack {
+		names[i] = f.rule
+	}
+	key := strings.Join(names, "\x00")
+	s := p.samples[key]
+	if s == nil {
+		s = &profileSample{stack: names}
+		p.samples[key] = s
+	}
+	s.invocations++
+	s.exclusive += exclusive
+
+	p.stack = p.stack[:len(p.stack)-1]
+	if len(p.stack) != 0 {
+		p.stack[len(p.stack)-1].children += elapsed
+	} else {
+		p.duration += elapsed
+	}
+}
+
+// Rules returns the statistics of all invoked rules, sorted by decreasing
+// exclusive time.
+func (p *Profiler) Rules() []*RuleProfile {
+	rules := make([]*RuleProfile, 0, len(p.rules))
+	for _, r := range p.rules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Exclusive != rules[j].Exclusive {
+			return rules[i].Exclusive > rules[j].Exclusive
+		}
+		return rules[i].Rule < rules[j].Rule
+	})
+	return rules
+}
+
+// HotSpots returns up to n positions where rules were invoked most often,
+// sorted by decreasing number of invocations.
+func (p *Profiler) HotSpots(n int) []HotSpot {
+	var spots []HotSpot
+	for e, count := range p.entries {
+		if count > 1 {
+			spots = append(spots, HotSpot{Rule: e.rule, Pos: e.pos, Invocations: count})
+		}
+	}
+	sort.Slice(spots, func(i, j int) bool {
+		a, b := spots[i], spots[j]
+		if a.Invocations != b.Invocations {
+			return a.Invocations > b.Invocations
+		}
+		if a.Pos != b.Pos {
+			return a.Pos < b.Pos
+		}
+		return a.Rule < b.Rule
+	})
+	if len(spots) > n {
+		spots = spots[:n]
+	}
+	return spots
+}
+
+// WriteTable writes the statistics of the rules and the ten hottest spots as
+// text tables.
+func (p *Profiler) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "rule\tinvocations\tsuccesses\tfailures\tconsumed\tinclusive\texclusive\t")
+	for _, r := range p.Rules() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%v\t%v\t\n", r.Rule, r.Invocations, r.Successes, r.Failures, r.Consumed, r.Inclusive, r.Exclusive)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	spots := p.HotSpots(10)
+	if len(spots) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "rule\tposition\tinvocations\t")
+	for _, s := range spots {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t\n", s.Rule, s.Pos, s.Invocations)
+	}
+	return tw.Flush()
+}
+
+// WritePprof writes the profile in the gzipped protocol buffer format read by
+// go tool pprof. Each rule is a function; the samples count the invocations
+// and their exclusive time by call stack.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	var b protoBuffer
+	// string indexes of the string table
+	indexes := map[string]int64{"": 0}
+	table := []string{""}
+	str := func(s string) int64 {
+		i, ok := indexes[s]
+		if !ok {
+			i = int64(len(table))
+			indexes[s] = i
+			table = append(table, s)
+		}
+		return i
+	}
+	valueType := func(typ, unit string) []byte {
+		var v protoBuffer
+		v.int(1, str(typ))
+		v.int(2, str(unit))
+		return v
+	}
+
+	b.message(1, valueType("invocations", "count"))
+	b.message(1, valueType("time", "nanoseconds"))
+
+	// rules get the same id as function and location
+	ids := make(map[string]uint64)
+	var rules []string
+	for _, r := range p.Rules() {
+		ids[r.Rule] = uint64(len(rules) + 1)
+		rules = append(rules, r.Rule)
+	}
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := p.samples[key]
+		var sample, locations, values protoBuffer
+		for i := len(s.stack) - 1; i >= 0; i-- {
+			locations.varint(ids[s.stack[i]])
+		}
+		values.varint(uint64(s.invocations))
+		values.varint(uint64(s.exclusive))
+		sample.message(1, locations)
+		sample.message(2, values)
+		b.message(2, sample)
+	}
+	for _, rule := range rules {
+		var location, line protoBuffer
+		line.uint(1, ids[rule])
+		location.uint(1, ids[rule])
+		location.message(4, line)
+		b.message(4, location)
+	}
+	for _, rule := range rules {
+		var function protoBuffer
+		function.uint(1, ids[rule])
+		function.int(2, str(rule))
+		function.int(3, str(rule))
+		b.message(5, function)
+	}
+	period := valueType("time", "nanoseconds")
+	for _, s := range table {
+		b.message(6, []byte(s))
+	}
+	if !p.start.IsZero() {
+		b.int(9, p.start.UnixNano())
+	}
+	b.int(10, int64(p.duration))
+	b.message(11, period)
+	b.int(12, 1)
+
+	z := gzip.NewWriter(w)
+	if _, err := z.Write(b); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+// protoBuffer encodes protocol buffer messages.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		*b = append(*b, byte(x)|0x80)
+		x >>= 7
+	}
+	*b = append(*b, byte(x))
+}
+
+func (b *protoBuffer) uint(field int, x uint64) {
+	b.varint(uint64(field) << 3)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int(field int, x int64) {
+	b.uint(field, uint64(x))
+}
+
+func (b *protoBuffer) message(field int, m []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(m)))
+	*b = append(*b, m...)
+}
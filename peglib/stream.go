@@ -0,0 +1,215 @@
+package peglib
+
+import (
+	"fmt"
+	"io"
+)
+
+// StreamError is returned by Stream if the rule does not match the input
+// following the last commit point.
+type StreamError struct {
+	Offset int64 // of the input following the last commit point
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("peglib: no match for the input at byte %d of the stream", e.Offset)
+}
+
+// WindowError is returned by Stream if the input following the last commit
+// point does not fit into the maximum window.
+type WindowError struct {
+	Offset int64 // of the input following the last commit point
+	Size   int   // the maximum size of the window
+}
+
+func (e *WindowError) Error() string {
+	return fmt.Sprintf("peglib: no commit point within %d bytes from byte %d of the stream", e.Size, e.Offset)
+}
+
+// StreamOptions configures StreamWith.
+type StreamOptions struct {
+	// MaxWindow is the maximum size of the window in bytes, or zero for
+	// DefaultMaxWindow.
+	MaxWindow int
+}
+
+// DefaultMaxWindow is the maximum size of the window of Stream.
+const DefaultMaxWindow = 64 << 20
+
+// streamState is the stream being parsed, if any.
+type streamState struct {
+	window []byte // the current input, with the terminating zero byte
+	offset int64  // of the window in the stream
+	eof    bool
+}
+
+var stream *streamState
+
+// commitSignal unwinds the rules from a commit point to Stream.
+type commitSignal struct {
+	rest []byte
+}
+
+// needInputSignal unwinds the rules to Stream if the result of a parse
+// depends on input that has not been read yet.
+type needInputSignal struct{}
+
+// streamBufferSize is the initial size of the buffer of Stream.
+const streamBufferSize = 64 * 1024
+
+// Stream parses the input read from r with rule, which must be generated with
+// the streaming option. The input is kept in a window that grows as needed.
+// When the rules reach $Commit, the value on top of the output stack is
+// passed to emit, the window is cut off at the current position and rule
+// starts over on the remaining input. A commit point is a cut: parsing never
+// backtracks over it. The commit is delayed by reading more input if the parse
+// so far has examined the end of the window. At the end of the input, rule has
+// to match all remaining input and its output is passed to emit, unless the
+// remaining input is empty.
+//
+// Ranges of the input within the values passed to emit are only valid until
+// emit returns. An error returned by emit or r stops the parse. If the window
+// would grow beyond DefaultMaxWindow, Stream returns a *WindowError.
+func Stream(rule func([]byte) []byte, r io.Reader, emit func(v interface{}) error) error {
+	return StreamWith(rule, r, StreamOptions{}, emit)
+}
+
+// StreamWith is like Stream with the given options.
+func StreamWith(rule func([]byte) []byte, r io.Reader, opts StreamOptions, emit func(v interface{}) error) error {
+	Reset()
+	defer Reset()
+
+	maxWindow := opts.MaxWindow
+	if maxWindow == 0 {
+		maxWindow = DefaultMaxWindow
+	}
+	size := streamBufferSize
+	if size > maxWindow+1 {
+		size = maxWindow + 1
+	}
+	buf := make([]byte, 0, size)
+	start := 0       // of the window in buf
+	var offset int64 // of the window in the stream
+	s := &streamState{}
+	read := true
+	for {
+		if read && !s.eof {
+			// reparsing after every read would take quadratic time with
+			// short reads, so read until the window grows by a quarter or
+			// the buffer is full
+			want := (len(buf) - start) / 4
+			if want == 0 {
+				want = 1
+			}
+			got := 0
+			for got < want && !s.eof {
+				if len(buf) == cap(buf)-1 {
+					if got != 0 {
+						break
+					}
+					// make room by dropping the committed input or by growing
+					if start > len(buf)/2 {
+						buf = buf[:copy(buf, buf[start:])]
+					} else {
+						if len(buf)-start >= maxWindow {
+							return &WindowError{Offset: offset, Size: maxWindow}
+						}
+						size := 2 * cap(buf)
+						if size > maxWindow+1 {
+							size = maxWindow + 1
+						}
+						grown := make([]byte, len(buf)-start, size)
+						copy(grown, buf[start:])
+						buf = grown
+					}
+					start = 0
+				}
+				n, err := r.Read(buf[len(buf) : cap(buf)-1])
+				buf = buf[:len(buf)+n]
+				got += n
+				if err == io.EOF {
+					s.eof = true
+				} else if err != nil {
+					return err
+				}
+			}
+		}
+
+		s.window, s.offset = append(buf[start:], 0), offset
+		rest, committed, needInput := parseWindow(rule, s)
+		switch {
+		case needInput:
+			read = true
+		case committed:
+			if err := emit(Top()); err != nil {
+				return err
+			}
+			consumed := len(s.window) - len(rest)
+			start += consumed
+			offset += int64(consumed)
+			read = false
+		case rest == nil || len(rest) != 1:
+			return &StreamError{Offset: offset}
+		default:
+			if len(s.window) != 1 {
+				if err := emit(Top()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// parseWindow runs rule on the window of s. It reports whether the rules
+// reached a commit point or need more input. In the latter case, the parser
+// state is restored to the start of the window.
+func parseWindow(rule func([]byte) []byte, s *streamState) (rest []byte, committed, needInput bool) {
+	popOutput(len(outputStack))
+	localsStack = localsStack[:0]
+	depth = 0
+	state := SaveState()
+	stream = s
+	examined = len(s.window)
+	defer func() {
+		signal := recover()
+		if signal != nil {
+			abortTrace()
+		}
+		stream = nil
+		switch signal := signal.(type) {
+		case nil:
+		case commitSignal:
+			rest, committed = signal.rest, true
+		case needInputSignal:
+			RestoreState(state)
+			needInput = true
+		default:
+			panic(signal)
+		}
+	}()
+
+	rest = rule(s.window)
+	if !s.eof && (examined <= 0 || len(rest) == 1) {
+		// the result may change with more input
+		RestoreState(state)
+		return nil, false, true
+	}
+	return rest, false, false
+}
+
+// Commit marks a commit point at input. Outside of Stream, it does nothing.
+func Commit(input []byte) {
+	s := stream
+	if s == nil || len(input) == len(s.window) {
+		// no progress since the start of the window
+		return
+	}
+	if Debug {
+		fmt.Printf("Commit(%d)\n", len(s.window)-len(input))
+	}
+	if !s.eof && examined <= 0 {
+		panic(needInputSignal{})
+	}
+	panic(commitSignal{rest: input})
+}
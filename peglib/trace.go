@@ -0,0 +1,193 @@
+package peglib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Tracer receives the rule invocations of parsers generated with the trace
+// option. Positions are byte offsets in the input of the outermost rule, or
+// in the stream with Stream.
+//
+// If a parse is abandoned, i.e. Stream reaches a commit point or needs more
+// input, ParseContext stops at a limit or a rule panics, Leave is called for
+// the running invocations as if they failed, the innermost first. Tracers
+// thus always see matching calls.
+type Tracer interface {
+	// Enter is called when rule starts at pos.
+	Enter(rule string, pos int)
+
+	// Leave is called when rule ends. If it matched, ok is true and end is
+	// the position after the match, otherwise end equals pos.
+	Leave(rule string, pos, end int, ok bool)
+}
+
+// Trace receives the rule invocations if it is not nil.
+var Trace Tracer
+
+// traceFrame is a running rule invocation.
+type traceFrame struct {
+	name string
+	pos  int
+}
+
+var traceStack []traceFrame
+var traceLength int // of the input of the outermost rule, plus its offset in a stream
+
+// TraceEnter reports that the rule name starts at input and returns the
+// position to be passed to TraceLeave.
+func TraceEnter(name string, input []byte) int {
+	if len(traceStack) == 0 {
+		traceLength = len(input)
+		if s := stream; s != nil {
+			traceLength += int(s.offset)
+		}
+	}
+	pos := traceLength - len(input)
+	traceStack = append(traceStack, traceFrame{name, pos})
+	if Debug {
+		fmt.Printf("TraceEnter(%q, %d)\n", name, pos)
+	}
+	if Trace != nil {
+		Trace.Enter(name, pos)
+	}
+	return pos
+}
+
+// TraceLeave reports that the rule name started at pos ends with rest, which
+// is nil if the rule did not match, and returns rest.
+func TraceLeave(name string, pos int, rest []byte) []byte {
+	traceStack = traceStack[:len(traceStack)-1]
+	end, ok := pos, rest != nil
+	if ok {
+		end = traceLength - len(rest)
+	}
+	if Debug {
+		fmt.Printf("TraceLeave(%q, %d, %d, %t)\n", name, pos, end, ok)
+	}
+	if Trace != nil {
+		Trace.Leave(name, pos, end, ok)
+	}
+	return rest
+}
+
+// abortTrace ends the running invocations of an abandoned parse, see Tracer.
+func abortTrace() {
+	for i := len(traceStack) - 1; i >= 0; i-- {
+		f := traceStack[i]
+		if Debug {
+			fmt.Printf("TraceLeave(%q, %d, %d, false)\n", f.name, f.pos, f.pos)
+		}
+		if Trace != nil {
+			Trace.Leave(f.name, f.pos, f.pos, false)
+		}
+	}
+	traceStack = traceStack[:0]
+}
+
+// TextTracer is a Tracer writing a line for each rule invocation, indented by
+// the nesting of the invocations, and a line for its result.
+type TextTracer struct {
+	w     io.Writer
+	depth int
+}
+
+// NewTextTracer returns a TextTracer writing to w.
+func NewTextTracer(w io.Writer) *TextTracer {
+	return &TextTracer{w: w}
+}
+
+func (t *TextTracer) Enter(rule string, pos int) {
+	fmt.Fprintf(t.w, "%s%s at %d\n", strings.Repeat("  ", t.depth), rule, pos)
+	t.depth++
+}
+
+func (t *TextTracer) Leave(rule string, pos, end int, ok bool) {
+	t.depth--
+	if !ok {
+		fmt.Fprintf(t.w, "%s%s failed at %d\n", strings.Repeat("  ", t.depth), rule, pos)
+		return
+	}
+	fmt.Fprintf(t.w, "%s%s matched %d-%d\n", strings.Repeat("  ", t.depth), rule, pos, end)
+}
+
+// ChromeTracer is a Tracer recording each rule invocation as a span, to be
+// written in the Chrome trace event format, as shown by chrome://tracing and
+// Perfetto. A span is named after the rule and the matched input range.
+type ChromeTracer struct {
+	events []chromeEvent
+	open   []int // indexes of the events of the running invocations
+	start  time.Time
+}
+
+type chromeEvent struct {
+	Name  string     `json:"name"`
+	Phase string     `json:"ph"`
+	Time  float64    `json:"ts"`  // in microseconds
+	Dur   float64    `json:"dur"` // in microseconds
+	PID   int        `json:"pid"`
+	TID   int        `json:"tid"`
+	Args  chromeArgs `json:"args"`
+}
+
+type chromeArgs struct {
+	Rule    string `json:"rule"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Matched bool   `json:"matched"`
+}
+
+// NewChromeTracer returns a ChromeTracer without spans.
+func NewChromeTracer() *ChromeTracer {
+	return &ChromeTracer{start: time.Now()}
+}
+
+func (t *ChromeTracer) Enter(rule string, pos int) {
+	t.open = append(t.open, len(t.events))
+	t.events = append(t.events, chromeEvent{
+		Name:  rule,
+		Phase: "X",
+		Time:  microseconds(time.Since(t.start)),
+		PID:   1,
+		TID:   1,
+		Args:  chromeArgs{Rule: rule, Start: pos, End: pos},
+	})
+}
+
+func (t *ChromeTracer) Leave(rule string, pos, end int, ok bool) {
+	e := &t.events[t.open[len(t.open)-1]]
+	t.open = t.open[:len(t.open)-1]
+	e.Dur = microseconds(time.Since(t.start)) - e.Time
+	e.Args.End = end
+	e.Args.Matched = ok
+	if ok {
+		e.Name = fmt.Sprintf("%s %d-%d", rule, pos, end)
+	} else {
+		e.Name = fmt.Sprintf("%s %d failed", rule, pos)
+	}
+}
+
+// WriteJSON writes the spans of the completed invocations as a JSON trace.
+func (t *ChromeTracer) WriteJSON(w io.Writer) error {
+	events := make([]chromeEvent, 0, len(t.events))
+	running := make(map[int]bool, len(t.open))
+	for _, i := range t.open {
+		running[i] = true
+	}
+	for i, e := range t.events {
+		if !running[i] {
+			events = append(events, e)
+		}
+	}
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []chromeEvent `json:"traceEvents"`
+		DisplayTimeUnit string        `json:"displayTimeUnit"`
+	}{events, "ns"})
+}
+
+func microseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
@@ -0,0 +1,241 @@
+// Package pegtest runs test cases against parsers generated by peg.
+//
+// Build compiles a grammar once per test into a program that parses any
+// number of inputs, which Parser.Test checks against expected JSON values in
+// parallel subtests.
+//
+// Build loads the program as a plugin into the test binary where possible, so
+// that the inputs are parsed in-process. As peglib keeps the state of a parse
+// in package variables, the inputs are then parsed one at a time, and a
+// crashing parser, e.g. by a stack overflow, ends the test binary. Otherwise
+// the program runs in subprocesses, which parse in parallel and fail only the
+// affected inputs if the parser crashes. Parsers generated ahead of time, as
+// used by Corpus, run in-process.
+//
+// A corpus is a directory with a subdirectory for each rule under test, e.g.
+// testdata/Value, containing a file for each case. A case file holds the input
+// followed by a line "--- expected" and the expected result:
+//
+//	1+23
+//	--- expected
+//	{"Left": "1", "Right": "23"}
+//
+// The expected result is either the output of the rule as JSON or, if the
+// input is rejected, "error at N" with the byte offset of the error. The
+// newline before the separator does not belong to the input. A file starting
+// with the separator line has an empty input. A file without
+// separator is a case without expectation, which fails until the expected
+// result is filled in by updating the corpus.
+package pegtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neelance/peg/peglib"
+)
+
+const separator = "\n--- expected\n"
+
+// Case is a test case of a corpus.
+type Case struct {
+	Rule     string
+	File     string
+	Input    []byte
+	Expected string // empty if the case has no expectation
+}
+
+// Name returns the name of the case, e.g. Value/number.txt.
+func (c *Case) Name() string {
+	return c.Rule + "/" + filepath.Base(c.File)
+}
+
+// LoadCorpus reads the cases below dir, sorted by rule and file name.
+func LoadCorpus(dir string) ([]*Case, error) {
+	rules, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var cases []*Case
+	for _, rule := range rules {
+		if !rule.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(dir, rule.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			c := &Case{Rule: rule.Name(), File: filepath.Join(dir, rule.Name(), f.Name())}
+			data, err := ioutil.ReadFile(c.File)
+			if err != nil {
+				return nil, err
+			}
+			c.Input = data
+			if i := bytes.LastIndex(data, []byte(separator)); i != -1 {
+				c.Input = data[:i]
+				c.Expected = strings.TrimSpace(string(data[i+len(separator):]))
+			} else if bytes.HasPrefix(data, []byte(separator[1:])) {
+				c.Input = data[:0]
+				c.Expected = strings.TrimSpace(string(data[len(separator)-1:]))
+			}
+			cases = append(cases, c)
+		}
+	}
+	sort.SliceStable(cases, func(i, j int) bool { return cases[i].Rule < cases[j].Rule })
+	return cases, nil
+}
+
+// Rules returns the names of the rules tested by cases.
+func Rules(cases []*Case) []string {
+	var names []string
+	for _, c := range cases {
+		if len(names) == 0 || names[len(names)-1] != c.Rule {
+			names = append(names, c.Rule)
+		}
+	}
+	return names
+}
+
+// Result parses the input of c with rule and returns the result in the format
+// of the corpus. The rule must be generated with the limits option, which
+// tracks the position of errors; other rules give an error result.
+func (c *Case) Result(rule func([]byte) []byte) string {
+	value, err := peglib.ParseContext(context.Background(), rule, c.Input, peglib.Limits{MaxDepth: 10000, Strict: true})
+	if e, ok := err.(*peglib.ParsingError); ok {
+		return fmt.Sprintf("error at %d", e.Position)
+	}
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	if value == nil {
+		// like peglib.Test
+		value = map[string]interface{}{}
+	}
+	b, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return string(b)
+}
+
+// Matches reports whether result is the expected result of c. JSON values
+// are compared regardless of formatting and the order of object keys.
+func (c *Case) Matches(result string) bool {
+	if c.Expected == "" {
+		return false
+	}
+	if c.Expected == result {
+		return true
+	}
+	var expected, actual interface{}
+	if json.Unmarshal([]byte(c.Expected), &expected) != nil || json.Unmarshal([]byte(result), &actual) != nil {
+		return false
+	}
+	a, _ := json.Marshal(expected)
+	b, _ := json.Marshal(actual)
+	return bytes.Equal(a, b)
+}
+
+// Update rewrites the file of c with result as the expected result.
+func (c *Case) Update(result string) error {
+	c.Expected = result
+	sep := separator
+	if len(c.Input) == 0 {
+		sep = separator[1:]
+	}
+	data := append(append([]byte(nil), c.Input...), sep+result+"\n"...)
+	return ioutil.WriteFile(c.File, data, 0666)
+}
+
+// RunCorpus runs the cases below dir with the given rules and writes a report
+// of the failed cases to out. With update, the expected results of failed
+// cases are replaced by the actual ones instead. It returns the number of
+// failed cases.
+func RunCorpus(dir string, rules map[string]func([]byte) []byte, update bool, out io.Writer) (int, error) {
+	cases, err := LoadCorpus(dir)
+	if err != nil {
+		return 0, err
+	}
+	failed, updated := 0, 0
+	for _, c := range cases {
+		rule, ok := rules[c.Rule]
+		if !ok {
+			fmt.Fprintf(out, "FAIL %s: undefined rule %s\n", c.Name(), c.Rule)
+			failed++
+			continue
+		}
+		result := c.Result(rule)
+		if c.Matches(result) {
+			continue
+		}
+		if update {
+			if err := c.Update(result); err != nil {
+				return failed, err
+			}
+			updated++
+			continue
+		}
+		failed++
+		fmt.Fprintf(out, "FAIL %s\n%s", c.Name(), diff(c.Expected, result))
+	}
+	if updated != 0 {
+		fmt.Fprintf(out, "updated %d of %d cases\n", updated, len(cases))
+	}
+	if failed == 0 {
+		fmt.Fprintf(out, "ok %d cases\n", len(cases))
+	}
+	return failed, nil
+}
+
+// diff returns the lines of expected and actual that differ, with the
+// unchanged lines around them. An expected JSON value is formatted like the
+// actual one first.
+func diff(expected, actual string) string {
+	if expected == "" {
+		return "  no expected result, got:\n" + indent(actual, "  + ")
+	}
+	var v interface{}
+	if json.Unmarshal([]byte(expected), &v) == nil {
+		if b, err := json.MarshalIndent(v, "", "  "); err == nil {
+			expected = string(b)
+		}
+	}
+	a, b := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var s strings.Builder
+	for _, line := range a[:prefix] {
+		s.WriteString("    " + line + "\n")
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		s.WriteString("  - " + line + "\n")
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		s.WriteString("  + " + line + "\n")
+	}
+	for _, line := range a[len(a)-suffix:] {
+		s.WriteString("    " + line + "\n")
+	}
+	return s.String()
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.Replace(s, "\n", "\n"+prefix, -1) + "\n"
+}
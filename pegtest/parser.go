@@ -0,0 +1,322 @@
+package pegtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/neelance/peg/peggen"
+)
+
+// parserMain answers parse requests read as JSON lines from standard input.
+// The format argument is the initialization of the map of rules, which is
+// exported for loading the parser as a plugin. The declarations of the parser
+// are appended to it.
+const parserMain = `package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/neelance/peg/peglib"
+)
+
+var PegtestRules = map[string]func([]byte) []byte{%s}
+
+type pegtestResponse struct {
+	Value interface{}
+	Error string
+}
+
+func main() {
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(nil, 1<<30)
+	out := json.NewEncoder(os.Stdout)
+	for in.Scan() {
+		var req struct {
+			Rule  string
+			Input []byte
+		}
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			out.Encode(pegtestResponse{Error: err.Error()})
+			continue
+		}
+		out.Encode(pegtestParse(req.Rule, req.Input))
+	}
+}
+
+func pegtestParse(name string, input []byte) (resp pegtestResponse) {
+	rule, ok := PegtestRules[name]
+	if !ok {
+		return pegtestResponse{Error: "undefined rule " + name}
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			resp = pegtestResponse{Error: fmt.Sprintf("panic: %%v", e)}
+		}
+	}()
+	peglib.Reset()
+	if rest := rule(append(input, 0)); len(rest) != 1 {
+		return pegtestResponse{}
+	}
+	if n := peglib.OutputCount(); n > 1 {
+		// like peglib.Test
+		return pegtestResponse{Error: fmt.Sprintf("%%d values left on the output stack", n)}
+	}
+	resp.Value = peglib.Top()
+	if resp.Value == nil {
+		// like peglib.Test
+		resp.Value = map[string]interface{}{}
+	}
+	return resp
+}
+`
+
+// Parser is a grammar compiled into a plugin or a program that parses inputs
+// with the entry rules of the grammar. A plugin is loaded into the test binary
+// and parses one input at a time, see peglib.ParseMutex. A program runs once
+// for each concurrent call of Parse, up to GOMAXPROCS times, and parses any
+// number of inputs, so that tests do not pay for building and starting it per
+// input.
+type Parser struct {
+	rules map[string]func([]byte) []byte // of the plugin, nil for a program
+
+	exe   string
+	idle  chan *parserProcess
+	slots chan struct{} // one for each running process
+
+	mu        sync.Mutex
+	processes []*parserProcess
+	closed    bool
+}
+
+type parserProcess struct {
+	cmd     *exec.Cmd
+	send    io.WriteCloser
+	receive *bufio.Scanner
+	stderr  bytes.Buffer
+}
+
+// Build compiles grammar with opts and returns a Parser for its entry rules.
+// The parser is built in a temporary directory of t, as if its source was in
+// the current directory, so that peglib is provided by the module of the test.
+// It is loaded into the test binary as a plugin if possible, which requires
+// cgo and fails e.g. for tests built with -race, otherwise it is built as a
+// program. Plugins stay loaded until the test binary exits. The processes are
+// stopped and the directory is removed when t and its subtests complete.
+func Build(t testing.TB, grammar string, opts *peggen.Options) *Parser {
+	t.Helper()
+	if errs := peggen.Check(grammar, opts).Errors(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	rules, err := peggen.EntryRules(grammar, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []string
+	for _, name := range rules {
+		entries = append(entries, fmt.Sprintf("%q: %s", name, name))
+	}
+	var src bytes.Buffer
+	fmt.Fprintf(&src, parserMain, strings.Join(entries, ", "))
+	for _, decl := range peggen.Compile(grammar, opts) {
+		src.WriteString("\n")
+		if err := format.Node(&src, token.NewFileSet(), decl); err != nil {
+			t.Fatal(err)
+		}
+		src.WriteString("\n")
+	}
+
+	dir := t.TempDir()
+	p := &Parser{
+		exe:   filepath.Join(dir, "parser"),
+		idle:  make(chan *parserProcess, runtime.GOMAXPROCS(0)),
+		slots: make(chan struct{}, runtime.GOMAXPROCS(0)),
+	}
+	t.Cleanup(p.close)
+	file := filepath.Join(dir, "parser.go")
+	if err := ioutil.WriteFile(file, src.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// the overlay places the source in the current directory without
+	// writing to it
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules, err := loadPlugin(dir, cwd, file); err == nil {
+		p.rules = rules
+		return p
+	}
+	virtual := filepath.Join(cwd, ".pegtest", "parser.go")
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {virtual: file}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlayFile := filepath.Join(dir, "overlay.json")
+	if err := ioutil.WriteFile(overlayFile, overlay, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command("go", "build", "-overlay", overlayFile, "-o", p.exe, virtual).CombinedOutput(); err != nil {
+		t.Fatalf("%s%v", output, err)
+	}
+	return p
+}
+
+// Parse parses input with rule and returns the output as JSON, or null if
+// rule does not match all of input.
+func (p *Parser) Parse(rule string, input []byte) (json.RawMessage, error) {
+	if p.rules != nil {
+		value, err := parseInProcess(p.rules, rule, input)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(value)
+	}
+	proc, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	req, err := json.Marshal(struct {
+		Rule  string
+		Input []byte
+	}{rule, input})
+	if err != nil {
+		p.release(proc)
+		return nil, err
+	}
+	if _, err := proc.send.Write(append(req, '\n')); err != nil || !proc.receive.Scan() {
+		return nil, p.crashed(proc)
+	}
+	var resp struct {
+		Value json.RawMessage
+		Error string
+	}
+	err = json.Unmarshal(proc.receive.Bytes(), &resp)
+	p.release(proc)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Value, nil
+}
+
+// Test runs a parallel subtest of t for each input, which checks that parsing
+// it with rule gives the expected JSON value, or null if rule must not match
+// all of the input.
+func (p *Parser) Test(t *testing.T, rule string, inputs map[string]string) {
+	for input, expectedJSON := range inputs {
+		input, expectedJSON := input, expectedJSON
+		t.Run(fmt.Sprintf("%q", input), func(t *testing.T) {
+			t.Parallel()
+			var expected, got interface{}
+			if err := json.Unmarshal([]byte(expectedJSON), &expected); err != nil {
+				t.Fatal(err)
+			}
+			output, err := p.Parse(rule, []byte(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(output, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("wrong result on %q:\nexpected %s\ngot      %s", input, expectedJSON, output)
+			}
+		})
+	}
+}
+
+// acquire returns an idle process, or starts one if fewer than the maximum
+// are running.
+func (p *Parser) acquire() (*parserProcess, error) {
+	select {
+	case proc := <-p.idle:
+		return proc, nil
+	default:
+	}
+	select {
+	case proc := <-p.idle:
+		return proc, nil
+	case p.slots <- struct{}{}:
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		<-p.slots
+		return nil, errors.New("pegtest: parser used after its test completed")
+	}
+	proc := &parserProcess{cmd: exec.Command(p.exe)}
+	proc.cmd.Stderr = &proc.stderr
+	send, err := proc.cmd.StdinPipe()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	receive, err := proc.cmd.StdoutPipe()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	if err := proc.cmd.Start(); err != nil {
+		<-p.slots
+		return nil, err
+	}
+	proc.send = send
+	proc.receive = bufio.NewScanner(receive)
+	proc.receive.Buffer(nil, 1<<30)
+	p.processes = append(p.processes, proc)
+	return proc, nil
+}
+
+func (p *Parser) release(proc *parserProcess) {
+	p.idle <- proc
+}
+
+// crashed stops a process that did not answer and returns its error output.
+func (p *Parser) crashed(proc *parserProcess) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	proc.send.Close()
+	err := proc.cmd.Wait()
+	for i, other := range p.processes {
+		if other == proc {
+			p.processes = append(p.processes[:i], p.processes[i+1:]...)
+			break
+		}
+	}
+	<-p.slots
+	return fmt.Errorf("pegtest: parser crashed: %v\n%s", err, proc.stderr.Bytes())
+}
+
+// close stops all processes.
+func (p *Parser) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, proc := range p.processes {
+		proc.send.Close()
+		proc.cmd.Wait()
+	}
+	p.processes = nil
+}
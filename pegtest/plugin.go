@@ -0,0 +1,79 @@
+package pegtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"sync/atomic"
+
+	"github.com/neelance/peg/peglib"
+)
+
+// plugins numbers the plugins loaded by Build, as a program cannot load two
+// plugins with the same package path.
+var plugins int32
+
+// loadPlugin builds the parser source in file as a plugin in dir and returns
+// its rules. The overlay places the source in a package of its own below cwd.
+func loadPlugin(dir, cwd, file string) (map[string]func([]byte) []byte, error) {
+	virtual := filepath.Join(cwd, "_pegtest", fmt.Sprintf("parser%d", atomic.AddInt32(&plugins, 1)))
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {filepath.Join(virtual, "parser.go"): file}})
+	if err != nil {
+		return nil, err
+	}
+	overlayFile := filepath.Join(dir, "plugin.json")
+	if err := ioutil.WriteFile(overlayFile, overlay, 0666); err != nil {
+		return nil, err
+	}
+	lib := filepath.Join(dir, "parser.so")
+	if output, err := exec.Command("go", "build", "-buildmode=plugin", "-overlay", overlayFile, "-o", lib, virtual).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s%v", output, err)
+	}
+	p, err := plugin.Open(lib)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("PegtestRules")
+	if err != nil {
+		return nil, err
+	}
+	rules, ok := sym.(*map[string]func([]byte) []byte)
+	if !ok {
+		return nil, errors.New("pegtest: PegtestRules of the plugin has the wrong type")
+	}
+	return *rules, nil
+}
+
+// parseInProcess parses input like the program built by Build.
+func parseInProcess(rules map[string]func([]byte) []byte, name string, input []byte) (value interface{}, err error) {
+	rule, ok := rules[name]
+	if !ok {
+		return nil, errors.New("undefined rule " + name)
+	}
+	peglib.ParseMutex.Lock()
+	defer peglib.ParseMutex.Unlock()
+	defer func() {
+		if e := recover(); e != nil {
+			value, err = nil, fmt.Errorf("panic: %v", e)
+		}
+	}()
+	peglib.Reset()
+	defer peglib.Reset()
+	if rest := rule(append(input[:len(input):len(input)], 0)); len(rest) != 1 {
+		return nil, nil
+	}
+	if n := peglib.OutputCount(); n > 1 {
+		// like peglib.Test
+		return nil, fmt.Errorf("%d values left on the output stack", n)
+	}
+	value = peglib.Top()
+	if value == nil {
+		// like peglib.Test
+		value = map[string]interface{}{}
+	}
+	return value, nil
+}
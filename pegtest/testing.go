@@ -0,0 +1,39 @@
+package pegtest
+
+import (
+	"flag"
+	"testing"
+)
+
+var update = flag.Bool("pegtest.update", false, "replace the expected results of failed corpus cases")
+
+// Corpus runs the cases below dir with the given rules as subtests of t,
+// named like the cases. With the flag -pegtest.update, the expected results
+// of failed cases are replaced by the actual ones instead.
+func Corpus(t *testing.T, dir string, rules map[string]func([]byte) []byte) {
+	t.Helper()
+	cases, err := LoadCorpus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name(), func(t *testing.T) {
+			rule, ok := rules[c.Rule]
+			if !ok {
+				t.Fatalf("undefined rule %s", c.Rule)
+			}
+			result := c.Result(rule)
+			if c.Matches(result) {
+				return
+			}
+			if *update {
+				if err := c.Update(result); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			t.Errorf("%s:\n%s", c.File, diff(c.Expected, result))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/neelance/peg/peggen"
+)
+
+// buildProgram compiles the parser for grammar together with src, which
+// contains the function main and its imports, to an executable. It is built in
+// a temporary directory below the current one, so that peglib is provided by
+// the enclosing module. The returned function removes the directory.
+func buildProgram(grammar string, opts *peggen.Options, src string) (string, func(), error) {
+	if errs := peggen.Check(grammar, opts).Errors(); len(errs) != 0 {
+		return "", nil, errs
+	}
+	parser, err := generateSource(grammar, "main", opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := ioutil.TempDir(".", ".peg")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	files := []string{filepath.Join(dir, "parser.go"), filepath.Join(dir, "main.go")}
+	if err := ioutil.WriteFile(files[0], parser, 0666); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if err := ioutil.WriteFile(files[1], []byte("package main\n\n"+src), 0666); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	exe := filepath.Join(dir, "program")
+	if output, err := exec.Command("go", append([]string{"build", "-o", exe}, files...)...).CombinedOutput(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("%s%v", output, err)
+	}
+	if !filepath.IsAbs(exe) {
+		exe = "." + string(filepath.Separator) + exe
+	}
+	return exe, cleanup, nil
+}
+
+// ruleOrDefault returns rule, or the first entry rule of grammar if rule is
+// empty.
+func ruleOrDefault(grammar, rule string) (string, error) {
+	if rule != "" {
+		return rule, nil
+	}
+	rules, err := peggen.EntryRules(grammar, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(rules) == 0 {
+		return "", errors.New("grammar has no rules")
+	}
+	return rules[0], nil
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neelance/peg/peggen"
+)
+
+// replMain answers parse requests read as JSON lines from standard input. The
+// format argument is the initialization of the map of rules.
+const replMain = `import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/neelance/peg/peglib"
+)
+
+var replRules = map[string]func([]byte) []byte{%s}
+
+func main() {
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(nil, 1<<30)
+	out := json.NewEncoder(os.Stdout)
+	for in.Scan() {
+		var req struct{ Rule, Input string }
+		json.Unmarshal(in.Bytes(), &req)
+		var resp struct {
+			Value interface{}
+			Error string
+		}
+		value, err := peglib.ParseContext(context.Background(), replRules[req.Rule], []byte(req.Input), peglib.Limits{MaxDepth: 10000})
+		switch {
+		case err != nil:
+			resp.Error = err.Error()
+		case value == nil:
+			// like peglib.Test
+			value = map[string]interface{}{}
+		}
+		resp.Value = value
+		out.Encode(resp)
+	}
+}
+`
+
+const replHelp = `Define a rule on one line with "rule name expression" or on several lines
+with "rule name", the expression and "end". Redefining a rule replaces it.
+Any other line is parsed with the entry rule; quote it to use Go escapes.
+Commands:
+  :entry rule     switch the entry rule
+  :rules          show the grammar
+  :delete rule    delete a rule
+  :load file      replace the grammar with a file
+  :quit           leave the REPL
+`
+
+// repl reads rule definitions and inputs interactively.
+func repl(args []string) int {
+	fs := flag.NewFlagSet("repl", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: peg repl [grammar.peg]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	r := newREPL(os.Stdout)
+	defer r.stop()
+	if fs.NArg() == 1 {
+		r.command(":load " + fs.Arg(0))
+	}
+	return r.run(os.Stdin)
+}
+
+// replState is the grammar of a REPL session and the program parsing with it.
+type replState struct {
+	out   io.Writer
+	names []string          // of the rules in definition order
+	rules map[string]string // source by name
+	entry string
+
+	// the program for the current grammar, if it has been built
+	server  *exec.Cmd
+	send    io.WriteCloser
+	receive *bufio.Scanner
+	cleanup func()
+}
+
+func newREPL(out io.Writer) *replState {
+	return &replState{out: out, rules: make(map[string]string)}
+}
+
+// run reads lines from in until it ends or :quit.
+func (r *replState) run(in io.Reader) int {
+	lines := bufio.NewScanner(in)
+	lines.Buffer(nil, 1<<30)
+	for {
+		fmt.Fprint(r.out, "> ")
+		if !lines.Scan() {
+			fmt.Fprintln(r.out)
+			return 0
+		}
+		line := lines.Text()
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == ":quit":
+			return 0
+		case strings.HasPrefix(fields[0], ":"):
+			r.command(line)
+		case fields[0] == "rule" && len(fields) == 2:
+			// a definition on several lines
+			src := line + "\n"
+			for {
+				fmt.Fprint(r.out, "... ")
+				if !lines.Scan() {
+					fmt.Fprintln(r.out)
+					return 0
+				}
+				src += lines.Text() + "\n"
+				if strings.TrimSpace(lines.Text()) == "end" {
+					break
+				}
+			}
+			r.define(fields[1], src)
+		case fields[0] == "rule" && len(fields) > 2:
+			expr := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "rule"))
+			expr = strings.TrimSpace(strings.TrimPrefix(expr, fields[1]))
+			r.define(fields[1], "rule "+fields[1]+"\n  "+expr+"\nend\n")
+		default:
+			r.parse(line)
+		}
+	}
+}
+
+func (r *replState) command(line string) {
+	fields := strings.Fields(line)
+	switch {
+	case fields[0] == ":entry" && len(fields) == 2:
+		if _, ok := r.rules[fields[1]]; !ok {
+			fmt.Fprintf(r.out, "undefined rule %s\n", fields[1])
+			return
+		}
+		r.entry = fields[1]
+	case fields[0] == ":rules" && len(fields) == 1:
+		fmt.Fprint(r.out, r.grammar())
+		if r.entry != "" {
+			fmt.Fprintf(r.out, "entry rule: %s\n", r.entry)
+		}
+	case fields[0] == ":delete" && len(fields) == 2:
+		if _, ok := r.rules[fields[1]]; !ok {
+			fmt.Fprintf(r.out, "undefined rule %s\n", fields[1])
+			return
+		}
+		delete(r.rules, fields[1])
+		for i, name := range r.names {
+			if name == fields[1] {
+				r.names = append(r.names[:i], r.names[i+1:]...)
+				break
+			}
+		}
+		if r.entry == fields[1] {
+			r.entry = ""
+		}
+		r.stop()
+	case fields[0] == ":load" && len(fields) == 2:
+		src, err := ioutil.ReadFile(fields[1])
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+			return
+		}
+		rules, err := splitRules(string(src))
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+			return
+		}
+		r.names, r.rules, r.entry = nil, make(map[string]string), ""
+		for _, rule := range rules {
+			r.names = append(r.names, rule.name)
+			r.rules[rule.name] = rule.src
+		}
+		r.stop()
+		r.report()
+	case fields[0] == ":help":
+		fmt.Fprint(r.out, replHelp)
+	default:
+		fmt.Fprintf(r.out, "unknown command %q, type :help for a list\n", line)
+	}
+}
+
+// define adds or replaces the rule name, unless its source is invalid.
+func (r *replState) define(name, src string) {
+	if _, err := peggen.EntryRules(src, nil); err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	if _, ok := r.rules[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.rules[name] = src
+	r.stop()
+	r.report()
+}
+
+// report prints the errors of the grammar.
+func (r *replState) report() {
+	for _, d := range peggen.Check(r.grammar(), nil).Errors() {
+		fmt.Fprintln(r.out, d)
+	}
+}
+
+func (r *replState) grammar() string {
+	var b strings.Builder
+	for _, name := range r.names {
+		b.WriteString(r.rules[name])
+	}
+	return b.String()
+}
+
+// parse parses input with the entry rule and prints the result.
+func (r *replState) parse(input string) {
+	if strings.HasPrefix(input, `"`) || strings.HasPrefix(input, "`") {
+		unquoted, err := strconv.Unquote(input)
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+			return
+		}
+		input = unquoted
+	}
+	if len(r.names) == 0 {
+		fmt.Fprintln(r.out, "no rules defined, type :help for help")
+		return
+	}
+	entry := r.entry
+	if entry == "" {
+		entries, err := peggen.EntryRules(r.grammar(), nil)
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+			return
+		}
+		entry = entries[0]
+	}
+	if r.server == nil {
+		if err := r.start(); err != nil {
+			fmt.Fprintln(r.out, err)
+			return
+		}
+	}
+
+	req, _ := json.Marshal(struct{ Rule, Input string }{entry, input})
+	if _, err := r.send.Write(append(req, '\n')); err != nil || !r.receive.Scan() {
+		fmt.Fprintln(r.out, "parser crashed")
+		r.stop()
+		return
+	}
+	var resp struct {
+		Value json.RawMessage
+		Error string
+	}
+	json.Unmarshal(r.receive.Bytes(), &resp)
+	if resp.Error != "" {
+		fmt.Fprintf(r.out, "error: %s\n", resp.Error)
+		return
+	}
+	fmt.Fprintf(r.out, "%s\n", resp.Value)
+}
+
+// start builds and starts the program for the current grammar.
+func (r *replState) start() error {
+	grammar := r.grammar()
+	names := append([]string(nil), r.names...)
+	sort.Strings(names)
+	var rules []string
+	for _, name := range names {
+		rules = append(rules, fmt.Sprintf("%q: %s", name, name))
+	}
+	opts := &peggen.Options{EntryRules: names, Limits: true}
+	exe, cleanup, err := buildProgram(grammar, opts, fmt.Sprintf(replMain, strings.Join(rules, ", ")))
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe)
+	cmd.Stderr = r.out
+	send, err := cmd.StdinPipe()
+	if err != nil {
+		cleanup()
+		return err
+	}
+	receive, err := cmd.StdoutPipe()
+	if err != nil {
+		cleanup()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		cleanup()
+		return err
+	}
+	r.server, r.send, r.cleanup = cmd, send, cleanup
+	r.receive = bufio.NewScanner(receive)
+	r.receive.Buffer(nil, 1<<30)
+	return nil
+}
+
+// stop ends the program, so that the next input rebuilds it.
+func (r *replState) stop() {
+	if r.server == nil {
+		return
+	}
+	r.send.Close()
+	r.server.Wait()
+	r.cleanup()
+	r.server = nil
+}
+
+// ruleSource is the source of a rule within a grammar.
+type ruleSource struct {
+	name string
+	src  string
+}
+
+// splitRules splits a grammar into its rules. Each rule has to start with a
+// line "rule name" and end with a line "end" at the start of a line.
+func splitRules(grammar string) ([]ruleSource, error) {
+	var rules []ruleSource
+	var current *ruleSource
+	for i, line := range strings.SplitAfter(grammar, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case current == nil && (len(fields) == 0 || strings.HasPrefix(fields[0], "#")):
+		case current == nil && len(fields) >= 2 && fields[0] == "rule" && strings.HasPrefix(line, "rule"):
+			current = &ruleSource{name: fields[1]}
+			current.src = line
+		case current == nil:
+			return nil, fmt.Errorf("line %d: expected a rule", i+1)
+		default:
+			current.src += line
+			if strings.TrimRight(line, " \t\r\n") == "end" {
+				if !strings.HasSuffix(current.src, "\n") {
+					current.src += "\n"
+				}
+				rules = append(rules, *current)
+				current = nil
+			}
+		}
+	}
+	if current != nil {
+		return nil, fmt.Errorf("rule %s has no end", current.name)
+	}
+	return rules, nil
+}
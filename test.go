@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/neelance/peg/peggen"
+	"github.com/neelance/peg/pegtest"
+)
+
+// testMain runs a corpus with the rules initialized by the format argument.
+const testMain = `import (
+	"fmt"
+	"os"
+
+	"github.com/neelance/peg/pegtest"
+)
+
+var testRules = map[string]func([]byte) []byte{%s}
+
+func main() {
+	failed, err := pegtest.RunCorpus(os.Args[1], testRules, os.Args[2] == "update", os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if failed != 0 {
+		os.Exit(1)
+	}
+}
+`
+
+// test runs the cases of a corpus against a grammar, see package pegtest for
+// the format of the corpus.
+func test(args []string) int {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	dir := fs.String("dir", "testdata", "`directory` of the corpus, with a subdirectory of cases for each rule")
+	update := fs.Bool("update", false, "replace the expected results of failed cases")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: peg test [flags] grammar.peg\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	filename := fs.Arg(0)
+	grammar, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	cases, err := pegtest.LoadCorpus(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(cases) == 0 {
+		fmt.Fprintf(os.Stderr, "no cases in %s\n", *dir)
+		return 1
+	}
+
+	// directories not named after a rule are reported as undefined entry rules
+	names := pegtest.Rules(cases)
+	var rules []string
+	for _, name := range names {
+		rules = append(rules, fmt.Sprintf("%q: %s", name, name))
+	}
+	opts := &peggen.Options{Filename: filename, EntryRules: names, Limits: true}
+	exe, cleanup, err := buildProgram(string(grammar), opts, fmt.Sprintf(testMain, strings.Join(rules, ", ")))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer cleanup()
+
+	mode := "check"
+	if *update {
+		mode = "update"
+	}
+	cmd := exec.Command(exe, *dir, mode)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			return exit.ExitCode()
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/neelance/peg/peggen"
+)
+
+// vet checks grammar files and prints the diagnostics. It exits with status 1
+// if any errors were found.
+func vet(args []string) int {
+	fs := flag.NewFlagSet("vet", flag.ExitOnError)
+	entry := fs.String("entry", "", "comma-separated `rules` called by users of the parser")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: peg vet [-entry rules] grammar.peg...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	opts := &peggen.Options{}
+	if *entry != "" {
+		opts.EntryRules = strings.Split(*entry, ",")
+	}
+
+	status := 0
+	for _, filename := range fs.Args() {
+		grammar, err := ioutil.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		opts.Filename = filename
+		diags := peggen.Check(string(grammar), opts)
+		for _, d := range diags {
+			fmt.Fprintln(os.Stderr, d)
+		}
+		if len(diags.Errors()) != 0 {
+			status = 1
+		}
+	}
+	return status
+}